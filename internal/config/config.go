@@ -0,0 +1,346 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiffAlgorithms lists the values accepted for Config.DiffAlgorithm, in the
+// order the commit overlay cycles through them. "" defers to git's own
+// diff.algorithm setting.
+var DiffAlgorithms = []string{"", "myers", "minimal", "patience", "histogram"}
+
+// BorderStyles lists the values accepted for Config.BorderStyle. "" means
+// "rounded"; "none" keeps the spacing of a border but draws nothing.
+var BorderStyles = []string{"", "rounded", "normal", "thick", "none"}
+
+// DetailRowNames lists the detail-pane rows that Config.DetailRows may name,
+// in their default order.
+var DetailRowNames = []string{"Branch", "Path", "Updated", "HEAD", "Status", "Sync", "Forked", "Created", "Labels"}
+
+// ListItemPlaceholders lists the {placeholders} Config.ListItemFormat may use.
+var ListItemPlaceholders = []string{
+	"name", "branch", "pin", "dirty", "labels", "author", "status", "ahead", "behind", "pr", "updated", "notes", "default",
+}
+
+// DefaultListItemFormat is the built-in list row layout.
+const DefaultListItemFormat = "{pin} {name} {>} {labels} {notes} {author} {status} {default}"
+
+// Config holds user preferences read from ~/.config/worktree-tui/config.json.
+// Every field is optional; zero values mean "use the default".
+type Config struct {
+	DiffAlgorithm string `json:"diffAlgorithm"` // passed as git show --diff-algorithm
+	NoShellPrompt bool   `json:"noShellPrompt"` // never show the first-run shell setup prompt
+	ReadOnly      bool   `json:"readOnly"`      // disable every action that changes the repo
+	SignCommits   bool   `json:"signCommits"`   // start new branches with a signed empty commit
+	// AutoPruneMerged offers, once at startup, to delete every worktree whose
+	// branch is merged and that has nothing uncommitted or unpushed.
+	AutoPruneMerged bool `json:"autoPruneMerged"`
+	// MaxWorktrees is a soft cap: creating past it asks for confirmation.
+	// 0 means no limit.
+	MaxWorktrees int `json:"maxWorktrees"`
+	// TmuxIntegration opens t/T shells as new tmux windows when running
+	// inside tmux, instead of suspending the TUI.
+	TmuxIntegration bool `json:"tmuxIntegration"`
+	// ConfirmCD makes c show where it is about to cd and wait for enter,
+	// instead of quitting straight away.
+	ConfirmCD bool `json:"confirmCd"`
+	// InstantQuit makes a single ctrl+c quit. By default a second ctrl+c
+	// within a couple of seconds is needed, so a stray one doesn't throw
+	// away a half-filled form.
+	InstantQuit bool `json:"instantQuit"`
+	// LazyEnrichment loads only name, branch and working-tree status for the
+	// list, and the rest (branch status, commits) for the selected worktree
+	// when it is selected. For large repos where launching is slow; sorting
+	// by commit time and the activity view only see loaded worktrees.
+	LazyEnrichment bool `json:"lazyEnrichment"`
+	// PrefetchPRs fetches every worktree's PR badge, a few at a time, as
+	// soon as the list loads rather than when each one is first selected.
+	// On by default.
+	PrefetchPRs bool `json:"prefetchPRs"`
+	// OverlayWidthPct and OverlayHeightPct size the commit and activity
+	// overlays as a percentage of the terminal, from 40 to 100 (default 80).
+	OverlayWidthPct  int `json:"overlayWidthPct"`
+	OverlayHeightPct int `json:"overlayHeightPct"`
+	// BorderStyle draws panes and modals with "rounded" (default), "normal",
+	// "thick" or no ("none") borders, for fonts where the rounded corners
+	// render badly.
+	BorderStyle string `json:"borderStyle"`
+
+	// ListItemFormat lays out each worktree row in the list. Words are
+	// separated by single spaces, and a word whose placeholders all come out
+	// empty is dropped. Everything after {>} is right-aligned. Placeholders:
+	// {name} {branch} {pin} {dirty} {labels} {author} {status} {ahead}
+	// {behind} {pr} {updated} {notes} {default}.
+	ListItemFormat string `json:"listItemFormat"`
+
+	// DetailRows orders (and, by omission, hides) the detail-pane rows.
+	DetailRows []string `json:"detailRows"`
+
+	// Slug tunes how the new-worktree form turns a name into a branch.
+	Slug SlugOptions `json:"slug"`
+
+	// Templates are named presets offered in the new-worktree type picker.
+	Templates []Template `json:"templates"`
+
+	// OpenCommands maps a file extension (".png") to the command o and O in
+	// the commit overlay open such files with; the file's path is appended.
+	// Other files open in $VISUAL/$EDITOR.
+	OpenCommands map[string]string `json:"openCommands"`
+}
+
+// DefaultOverlayPct is the default overlay size, in percent of the terminal.
+const DefaultOverlayPct = 80
+
+// Template bundles the settings for a recurring kind of worktree. String
+// fields may use the placeholders {root} (repo root), {name} (display
+// name), {branch} and {slug} (branch with slashes replaced by dashes).
+type Template struct {
+	Name        string   `json:"name"`        // shown in the type picker
+	Type        string   `json:"type"`        // branch type prefix, e.g. "fix"
+	Path        string   `json:"path"`        // worktree location; default <root>/.wt/{slug}
+	Description string   `json:"description"` // pre-fills the description field
+	Base        string   `json:"base"`        // start point: a ref, or "latest-tag"; default HEAD
+	Commands    []string `json:"commands"`    // run with sh -c in the new worktree, in order
+}
+
+// SlugOptions controls branch-name derivation. The zero value lowercases,
+// keeps letters, digits and slashes, and turns runs of spaces, underscores
+// and hyphens into one hyphen.
+type SlugOptions struct {
+	PreserveCase bool   `json:"preserveCase"` // keep "PROJ-123" as typed
+	Keep         string `json:"keep"`         // extra characters kept verbatim, e.g. "_."
+	MaxLength    int    `json:"maxLength"`    // cut the slug to this many characters; 0 = no limit
+}
+
+// BaseLatestTag is the Template.Base value meaning "the most recent tag".
+const BaseLatestTag = "latest-tag"
+
+// Expand substitutes the template placeholders in s.
+func (t Template) Expand(s, root, name, branch string) string {
+	return strings.NewReplacer(
+		"{root}", root,
+		"{name}", name,
+		"{branch}", branch,
+		"{slug}", strings.ReplaceAll(branch, "/", "-"),
+	).Replace(s)
+}
+
+// EnvNoShellPrompt overrides Config.NoShellPrompt when set to a true value.
+const EnvNoShellPrompt = "WORKTREE_TUI_NO_SHELL_PROMPT"
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		DetailRows:       append([]string(nil), DetailRowNames...),
+		ListItemFormat:   DefaultListItemFormat,
+		PrefetchPRs:      true,
+		OverlayWidthPct:  DefaultOverlayPct,
+		OverlayHeightPct: DefaultOverlayPct,
+	}
+}
+
+// Path returns the location of the config file.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "worktree-tui", "config.json"), nil
+}
+
+// Load reads the config file and applies environment overrides. It always
+// returns a usable Config: a missing file yields the defaults, and invalid
+// values are replaced by their defaults with the problems reported in the
+// returned error.
+func Load() (Config, error) {
+	cfg, err := loadFile()
+	cfg.applyEnv()
+	return cfg, err
+}
+
+func loadFile() (Config, error) {
+	cfg := Default()
+	p, err := Path()
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return cfg, nil // no config file — defaults
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("%s: %w", p, err)
+	}
+	return cfg, cfg.validate()
+}
+
+// applyEnv lets environment variables override file settings.
+func (c *Config) applyEnv() {
+	if v := os.Getenv(EnvNoShellPrompt); v != "" {
+		b, err := strconv.ParseBool(v)
+		c.NoShellPrompt = err != nil || b // any non-boolean value counts as set
+	}
+}
+
+// validate resets invalid fields to their defaults and reports each one.
+func (c *Config) validate() error {
+	var errs []error
+	if !contains(DiffAlgorithms, c.DiffAlgorithm) {
+		errs = append(errs, fmt.Errorf("config: unknown diffAlgorithm %q", c.DiffAlgorithm))
+		c.DiffAlgorithm = ""
+	}
+	if !contains(BorderStyles, c.BorderStyle) {
+		errs = append(errs, fmt.Errorf("config: unknown borderStyle %q", c.BorderStyle))
+		c.BorderStyle = ""
+	}
+	if c.DetailRows == nil {
+		c.DetailRows = append([]string(nil), DetailRowNames...)
+	}
+	var rows []string
+	for _, r := range c.DetailRows {
+		if name, ok := lookupFold(DetailRowNames, r); ok {
+			rows = append(rows, name)
+		} else {
+			errs = append(errs, fmt.Errorf("config: unknown detail row %q ignored", r))
+		}
+	}
+	c.DetailRows = rows
+	if c.MaxWorktrees < 0 {
+		errs = append(errs, fmt.Errorf("config: maxWorktrees must not be negative, got %d", c.MaxWorktrees))
+		c.MaxWorktrees = 0
+	}
+	if c.Slug.MaxLength < 0 {
+		errs = append(errs, fmt.Errorf("config: slug.maxLength must not be negative, got %d", c.Slug.MaxLength))
+		c.Slug.MaxLength = 0
+	}
+	if i := strings.IndexAny(c.Slug.Keep, " ~^:?*[\\"); i >= 0 {
+		errs = append(errs, fmt.Errorf("config: slug.keep has %q, which git does not allow in branch names", c.Slug.Keep[i]))
+		c.Slug.Keep = ""
+	}
+	if err := clampOverlayPct("overlayWidthPct", &c.OverlayWidthPct); err != nil {
+		errs = append(errs, err)
+	}
+	if err := clampOverlayPct("overlayHeightPct", &c.OverlayHeightPct); err != nil {
+		errs = append(errs, err)
+	}
+	if c.ListItemFormat == "" {
+		c.ListItemFormat = DefaultListItemFormat
+	}
+	for _, p := range placeholderRe.FindAllStringSubmatch(c.ListItemFormat, -1) {
+		if p[1] != ">" && !contains(ListItemPlaceholders, p[1]) {
+			errs = append(errs, fmt.Errorf("config: unknown listItemFormat placeholder {%s}", p[1]))
+			c.ListItemFormat = DefaultListItemFormat
+			break
+		}
+	}
+	seen := make(map[string]bool)
+	var templates []Template
+	for _, t := range c.Templates {
+		switch {
+		case t.Name == "":
+			errs = append(errs, errors.New("config: template without a name ignored"))
+		case seen[t.Name]:
+			errs = append(errs, fmt.Errorf("config: duplicate template %q ignored", t.Name))
+		default:
+			seen[t.Name] = true
+			templates = append(templates, t)
+		}
+	}
+	c.Templates = templates
+	return errors.Join(errs...)
+}
+
+// clampOverlayPct defaults an unset overlay percentage and pulls an
+// out-of-range one into 40–100, reporting the latter.
+func clampOverlayPct(name string, pct *int) error {
+	switch {
+	case *pct == 0:
+		*pct = DefaultOverlayPct
+	case *pct < 40 || *pct > 100:
+		clamped := min(max(*pct, 40), 100)
+		err := fmt.Errorf("config: %s must be 40–100, got %d; using %d", name, *pct, clamped)
+		*pct = clamped
+		return err
+	}
+	return nil
+}
+
+// placeholderRe matches a {placeholder} in ListItemFormat.
+var placeholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// lookupFold returns the entry of list equal to s ignoring case.
+func lookupFold(list []string, s string) (string, bool) {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ── Repo registry ─────────────────────────────────────────────────────────────
+
+// reposPath returns the location of the known-repos registry.
+func reposPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "worktree-tui", "repos.json"), nil
+}
+
+// LoadRepos returns the registered repo roots, most recently registered first.
+func LoadRepos() ([]string, error) {
+	p, err := reposPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, nil // nothing registered yet
+	}
+	var repos []string
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return repos, nil
+}
+
+// RegisterRepo moves root to the front of the registry, adding it if new.
+func RegisterRepo(root string) error {
+	p, err := reposPath()
+	if err != nil {
+		return err
+	}
+	repos, _ := LoadRepos()
+	updated := []string{root}
+	for _, r := range repos {
+		if r != root {
+			updated = append(updated, r)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
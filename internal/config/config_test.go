@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	c := Default()
+	if err := c.validate(); err != nil {
+		t.Errorf("Default().validate() = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*Config)
+		errHas  string
+		checkFn func(Config) bool
+	}{
+		{"diff algorithm", func(c *Config) { c.DiffAlgorithm = "quick" }, "diffAlgorithm",
+			func(c Config) bool { return c.DiffAlgorithm == "" }},
+		{"border style", func(c *Config) { c.BorderStyle = "dotted" }, "borderStyle",
+			func(c Config) bool { return c.BorderStyle == "" }},
+		{"detail row", func(c *Config) { c.DetailRows = []string{"nope"} }, "detail row",
+			func(c Config) bool { return len(c.DetailRows) == 0 }},
+		{"max worktrees", func(c *Config) { c.MaxWorktrees = -1 }, "maxWorktrees",
+			func(c Config) bool { return c.MaxWorktrees == 0 }},
+		{"slug max length", func(c *Config) { c.Slug.MaxLength = -5 }, "slug.maxLength",
+			func(c Config) bool { return c.Slug.MaxLength == 0 }},
+		{"slug keep", func(c *Config) { c.Slug.Keep = "_~" }, "slug.keep",
+			func(c Config) bool { return c.Slug.Keep == "" }},
+		{"overlay width", func(c *Config) { c.OverlayWidthPct = 20 }, "overlayWidthPct",
+			func(c Config) bool { return c.OverlayWidthPct == 40 }},
+		{"overlay height", func(c *Config) { c.OverlayHeightPct = 120 }, "overlayHeightPct",
+			func(c Config) bool { return c.OverlayHeightPct == 100 }},
+		{"list placeholder", func(c *Config) { c.ListItemFormat = "{name} {bogus}" }, "{bogus}",
+			func(c Config) bool { return c.ListItemFormat == DefaultListItemFormat }},
+		{"template name", func(c *Config) { c.Templates = []Template{{Type: "fix"}} }, "without a name",
+			func(c Config) bool { return len(c.Templates) == 0 }},
+		{"duplicate template", func(c *Config) { c.Templates = []Template{{Name: "a"}, {Name: "a"}} }, "duplicate template",
+			func(c Config) bool { return len(c.Templates) == 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.edit(&c)
+			err := c.validate()
+			if err == nil || !strings.Contains(err.Error(), tt.errHas) {
+				t.Errorf("validate() = %v, want an error mentioning %q", err, tt.errHas)
+			}
+			if !tt.checkFn(c) {
+				t.Errorf("invalid value not reset: %+v", c)
+			}
+		})
+	}
+}
+
+func TestValidateKeepsValidValues(t *testing.T) {
+	c := Default()
+	c.DiffAlgorithm = DiffAlgorithms[len(DiffAlgorithms)-1]
+	c.DetailRows = []string{strings.ToUpper(DetailRowNames[0])}
+	c.Slug = SlugOptions{PreserveCase: true, Keep: "_.", MaxLength: 30}
+	c.OverlayWidthPct = 70
+	if err := c.validate(); err != nil {
+		t.Fatalf("validate() = %v", err)
+	}
+	if c.DetailRows[0] != DetailRowNames[0] {
+		t.Errorf("detail row %q not matched case-insensitively", c.DetailRows[0])
+	}
+	if c.Slug != (SlugOptions{PreserveCase: true, Keep: "_.", MaxLength: 30}) || c.OverlayWidthPct != 70 {
+		t.Errorf("valid values changed: %+v", c)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv(EnvNoShellPrompt, "")
+	p, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"maxWorktrees": -2, "slug": {"preserveCase": true, "maxLength": 20}}`
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "maxWorktrees") {
+		t.Errorf("Load() error = %v, want one about maxWorktrees", err)
+	}
+	if c.MaxWorktrees != 0 || !c.Slug.PreserveCase || c.Slug.MaxLength != 20 {
+		t.Errorf("Load() = %+v, want maxWorktrees reset and the slug options kept", c)
+	}
+	if c.OverlayWidthPct != DefaultOverlayPct {
+		t.Errorf("unset overlayWidthPct = %d, want the default %d", c.OverlayWidthPct, DefaultOverlayPct)
+	}
+}
+
+func TestLoadBadJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	p, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load()
+	if err == nil {
+		t.Error("Load() of malformed JSON returned no error")
+	}
+	if c.ListItemFormat != DefaultListItemFormat {
+		t.Errorf("Load() of malformed JSON = %+v, want the defaults", c)
+	}
+}
+
+func TestEnvNoShellPrompt(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  bool
+	}{{"1", true}, {"true", true}, {"0", false}, {"false", false}, {"yes", true}} {
+		t.Setenv(EnvNoShellPrompt, tt.value)
+		var c Config
+		c.applyEnv()
+		if c.NoShellPrompt != tt.want {
+			t.Errorf("%s=%q: NoShellPrompt = %v, want %v", EnvNoShellPrompt, tt.value, c.NoShellPrompt, tt.want)
+		}
+	}
+}
@@ -3,12 +3,14 @@ package git
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/agnishcc/worktree-tui/internal/types"
@@ -30,15 +32,63 @@ func run(args ...string) (string, error) {
 // runInDir executes a git command with the given directory as CWD.
 // On failure the returned error includes git's stderr output.
 func runInDir(dir string, args ...string) (string, error) {
+	out, err := runInDirRaw(dir, args...)
+	return strings.TrimSpace(out), err
+}
+
+// runInDirRaw is runInDir without trimming, for output where leading
+// whitespace is significant (e.g. the status columns of status --porcelain).
+func runInDirRaw(dir string, args ...string) (string, error) {
+	return runInDirAllowing(dir, 0, args...)
+}
+
+// runInDirAllowing is runInDirRaw for commands that report a result through
+// exit code ok (merge-tree exits 1 when the merge conflicts). Exiting with ok
+// is not an error unless something was written to stderr, since such
+// commands tend to use the same code for failures (merge-tree for a bad ref).
+func runInDirAllowing(dir string, ok int, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
+	cmd.Env = EnvFor(dir)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == ok && stderr.Len() == 0 {
+		return string(out), nil
+	}
 	if err != nil && stderr.Len() > 0 {
-		return strings.TrimSpace(string(out)), fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+		return string(out), fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
 	}
-	return strings.TrimSpace(string(out)), err
+	return string(out), err
+}
+
+// startRoot is the top of the worktree the tool was started in, as the
+// inherited environment (GIT_DIR and GIT_WORK_TREE included) resolves it.
+var startRoot = sync.OnceValue(func() string {
+	root, _ := run("rev-parse", "--show-toplevel")
+	return root
+})
+
+// EnvFor returns the environment for a command run in dir; nil means the
+// inherited one. GIT_DIR and GIT_WORK_TREE, when set, describe the worktree
+// the tool was started in, and would point a git command in any other
+// worktree back at that one. Such commands get them removed, so git finds
+// the repository from dir as usual.
+func EnvFor(dir string) []string {
+	if dir == "" || (os.Getenv("GIT_DIR") == "" && os.Getenv("GIT_WORK_TREE") == "") {
+		return nil
+	}
+	if root := startRoot(); root != "" && samePath(dir, root) {
+		return nil
+	}
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "GIT_DIR=") && !strings.HasPrefix(kv, "GIT_WORK_TREE=") {
+			env = append(env, kv)
+		}
+	}
+	return env
 }
 
 // IsGitRepo returns true if the current directory is inside a git repository.
@@ -51,6 +101,7 @@ func IsGitRepo() bool {
 func HasCommits(repoRoot string) bool {
 	cmd := exec.Command("git", "rev-parse", "HEAD")
 	cmd.Dir = repoRoot
+	cmd.Env = EnvFor(repoRoot)
 	return cmd.Run() == nil
 }
 
@@ -59,18 +110,114 @@ func InitRepo() error {
 	return exec.Command("git", "init").Run()
 }
 
-// GetRepoRoot returns the absolute path to the repository root.
+// GetRepoRoot returns the absolute path to the repository root. In a
+// bare-layout repo launched from outside any worktree, that is the directory
+// holding the bare repo (e.g. the parent of .bare).
 func GetRepoRoot() (string, error) {
-	return run("rev-parse", "--show-toplevel")
+	root, err := run("rev-parse", "--show-toplevel")
+	if err != nil && IsBareLayout() {
+		if common, cerr := GetCommonDir(); cerr == nil {
+			return filepath.Dir(common), nil
+		}
+	}
+	return root, err
+}
+
+// GetCommonDir returns the absolute path of the git directory shared by all
+// worktrees.
+func GetCommonDir() (string, error) {
+	return run("rev-parse", "--path-format=absolute", "--git-common-dir")
+}
+
+// IsBareLayout reports whether the repo is a bare repository with every
+// branch checked out in a linked worktree, so there is no main checkout.
+func IsBareLayout() bool {
+	out, _ := run("config", "--bool", "core.bare")
+	return out == "true"
+}
+
+// bareRepoName names a bare repo after its directory: "proj.git" → "proj",
+// and a conventional ".bare" or ".git" dir after its parent.
+func bareRepoName(commonDir string) string {
+	base := filepath.Base(commonDir)
+	if base == ".bare" || base == ".git" {
+		base = filepath.Base(filepath.Dir(commonDir))
+	}
+	return strings.TrimSuffix(base, ".git")
+}
+
+// GetMainWorktreePath returns the path of the repo's primary worktree, which
+// is the same regardless of which linked worktree the tool runs from.
+func GetMainWorktreePath() (string, error) {
+	out, err := run("worktree", "list", "--porcelain", "-z")
+	if err != nil {
+		return "", err
+	}
+	wts := parseWorktreeList(out)
+	if len(wts) == 0 || wts[0].Path == "" {
+		return "", fmt.Errorf("unexpected git worktree list output")
+	}
+	return wts[0].Path, nil
+}
+
+// GetEnclosingRepo returns the main worktree of the repository the current
+// one is nested in: the superproject of a submodule, or any other repo whose
+// working tree contains this repo's directory. It returns "" when there is
+// none. This catches launching from a nested repo and seeing its worktrees
+// rather than the outer repo's.
+func GetEnclosingRepo() string {
+	if sp, err := run("rev-parse", "--show-superproject-working-tree"); err == nil && sp != "" {
+		return sp
+	}
+	root, err := GetRepoRoot()
+	if err != nil {
+		return ""
+	}
+	parent := filepath.Dir(root)
+	if parent == root {
+		return ""
+	}
+	if _, err := runInDir(parent, "rev-parse", "--show-toplevel"); err != nil {
+		return ""
+	}
+	out, err := runInDir(parent, "worktree", "list", "--porcelain", "-z")
+	if err != nil {
+		return ""
+	}
+	wts := parseWorktreeList(out)
+	if len(wts) == 0 {
+		return ""
+	}
+	// A linked worktree kept inside its own repo's main worktree (.wt/…)
+	// is not nested in anything else.
+	if main, err := GetMainWorktreePath(); err == nil && main == wts[0].Path {
+		return ""
+	}
+	return wts[0].Path
+}
+
+// GetUserIdentity returns the configured user.name and user.email.
+func GetUserIdentity() (name, email string) {
+	name, _ = run("config", "user.name")
+	email, _ = run("config", "user.email")
+	return name, email
 }
 
 // GetRepoInfo returns the repo's base name and the current branch name.
 func GetRepoInfo() (name, branch string, err error) {
-	root, err := run("rev-parse", "--show-toplevel")
-	if err != nil {
-		return "", "", err
+	if IsBareLayout() {
+		common, err := GetCommonDir()
+		if err != nil {
+			return "", "", err
+		}
+		name = bareRepoName(common)
+	} else {
+		root, err := run("rev-parse", "--show-toplevel")
+		if err != nil {
+			return "", "", err
+		}
+		name = filepath.Base(root)
 	}
-	name = filepath.Base(root)
 	branch, err = run("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		branch = "unknown"
@@ -87,16 +234,24 @@ func getDefaultBranch() string {
 			return parts[1]
 		}
 	}
+	// A bare clone has no origin/HEAD, but its own HEAD names the branch
+	// it was cloned at.
+	if IsBareLayout() {
+		if common, err := GetCommonDir(); err == nil {
+			if out, err := runInDir(common, "symbolic-ref", "--short", "HEAD"); err == nil && out != "" {
+				return out
+			}
+		}
+	}
 	if _, err := run("rev-parse", "--verify", "main"); err == nil {
 		return "main"
 	}
 	return "master"
 }
 
-// GetBranchStatus returns how many commits a branch is ahead/behind the default
-// branch, and whether it has been merged.
-func GetBranchStatus(branch string) (ahead, behind int, merged bool, err error) {
-	def := getDefaultBranch()
+// GetBranchStatus returns how many commits a branch is ahead/behind def
+// (usually the default branch), and whether it has been merged into it.
+func GetBranchStatus(branch, def string) (ahead, behind int, merged bool, err error) {
 	if branch == def {
 		return 0, 0, false, nil
 	}
@@ -109,7 +264,8 @@ func GetBranchStatus(branch string) (ahead, behind int, merged bool, err error)
 	}
 	if out, e := run("branch", "--merged", def); e == nil {
 		for _, line := range strings.Split(out, "\n") {
-			if strings.TrimSpace(strings.TrimPrefix(line, "* ")) == branch {
+			// "* " marks the current branch, "+ " one checked out elsewhere.
+			if strings.TrimLeft(line, "*+ ") == branch {
 				merged = true
 				break
 			}
@@ -118,10 +274,66 @@ func GetBranchStatus(branch string) (ahead, behind int, merged bool, err error)
 	return ahead, behind, merged, nil
 }
 
+// UnpushedCount returns how many commits branch has that its upstream
+// lacks; 0 when it has no upstream.
+func UnpushedCount(branch string) int {
+	out, err := run("rev-list", "--count", branch+"@{upstream}.."+branch)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(out)
+	return n
+}
+
+// GetMergeBaseInfo returns the short SHA of branch's merge-base with def and
+// how long ago that commit was made, e.g. "3 weeks ago".
+func GetMergeBaseInfo(branch, def string) (sha string, age string, err error) {
+	base, err := run("merge-base", def, branch)
+	if err != nil {
+		return "", "", err
+	}
+	out, err := run("log", "-1", "--format=%h%x00%cr", base)
+	if err != nil {
+		return "", "", err
+	}
+	sha, age, _ = strings.Cut(out, "\x00")
+	return sha, age, nil
+}
+
+// BranchStart returns when work on branch began: the committer time of its
+// oldest commit that def lacks, or of its merge-base with def when it has
+// none. unique reports which of the two it is.
+func BranchStart(branch, def string) (start time.Time, unique bool, err error) {
+	out, err := run("log", "--reverse", "--format=%ct", def+".."+branch)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	first, _, _ := strings.Cut(out, "\n")
+	if first == "" {
+		base, err := run("merge-base", def, branch)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if first, err = run("log", "-1", "--format=%ct", base); err != nil {
+			return time.Time{}, false, err
+		}
+	} else {
+		unique = true
+	}
+	secs, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Unix(secs, 0), unique, nil
+}
+
 // ListWorktrees returns all worktrees for the current repo, enriched with
-// user metadata and branch status.
-func ListWorktrees() ([]types.Worktree, error) {
-	out, err := run("worktree", "list", "--porcelain")
+// user metadata and branch status. Branch status compares against compare,
+// or the default branch when it is empty. With lazy set, only the cheap
+// fields (metadata, working-tree status) are filled in; EnrichWorktree adds
+// the rest for one worktree at a time.
+func ListWorktrees(lazy bool, compare string) ([]types.Worktree, error) {
+	out, err := run("worktree", "list", "--porcelain", "-z")
 	if err != nil {
 		return nil, fmt.Errorf("git worktree list: %w", err)
 	}
@@ -129,182 +341,994 @@ func ListWorktrees() ([]types.Worktree, error) {
 	root, _ := GetRepoRoot()
 	meta, _ := readMeta(root)
 
+	def := compare
+	if def == "" {
+		def = getDefaultBranch()
+	}
 	var worktrees []types.Worktree
-	for i, block := range strings.Split(strings.TrimSpace(out), "\n\n") {
-		block = strings.TrimSpace(block)
-		if block == "" {
-			continue
+	for _, wt := range parseWorktreeList(out) {
+		if wt.Branch == "(bare)" {
+			continue // the bare repo itself has no working tree to show
 		}
-		wt := types.Worktree{IsMain: i == 0}
-		for _, line := range strings.Split(block, "\n") {
-			switch {
-			case strings.HasPrefix(line, "worktree "):
-				wt.Path = strings.TrimPrefix(line, "worktree ")
-			case strings.HasPrefix(line, "branch "):
-				wt.Branch = strings.TrimPrefix(line, "branch refs/heads/")
-				wt.Name = wt.Branch // default display name
-			case line == "detached":
-				wt.Branch = "(detached)"
-				wt.Name = "(detached)"
-			case line == "bare":
-				wt.Branch = "(bare)"
-				wt.Name = "(bare)"
+		if wt.IsMain && os.Getenv("GIT_WORK_TREE") != "" {
+			// With the work tree given only by the environment, git lists
+			// the main worktree at its git dir.
+			if common, err := GetCommonDir(); err == nil && samePath(wt.Path, common) {
+				wt.Path = startRoot()
 			}
 		}
-		if wt.Name == "" {
-			wt.Name = filepath.Base(wt.Path)
+		if _, err := os.Stat(wt.Path); errors.Is(err, os.ErrNotExist) {
+			wt.Missing = true
+		}
+		if wt.Branch == "(detached)" && !wt.Missing {
+			if tag, e := runInDir(wt.Path, "describe", "--exact-match", "--tags", "HEAD"); e == nil && tag != "" {
+				wt.Tag = tag
+				wt.Name = "at tag " + tag
+			}
 		}
 
-		// Overlay user metadata (name, description, createdFrom).
+		// Overlay user metadata (name, description, createdFrom, notes).
 		if m, ok := meta[wt.Branch]; ok {
 			if m.Name != "" {
 				wt.Name = m.Name
 			}
 			wt.Description = m.Description
 			wt.CreatedFrom = m.CreatedFrom
+			wt.Notes = m.Notes
+			wt.Labels = m.Labels
+		}
+		if fi, err := os.Stat(scratchpadPath(root, wt.Branch)); err == nil && fi.Size() > 0 {
+			wt.HasScratchpad = true
 		}
 
-		// Branch status and detail extras (skip for main worktree).
-		if !wt.IsMain {
-			wt.Ahead, wt.Behind, wt.IsMerged, _ = GetBranchStatus(wt.Branch)
+		// Every git command run inside a deleted directory fails; there is
+		// nothing more to load.
+		if wt.Missing {
+			wt.Enriched = true
+			worktrees = append(worktrees, wt)
+			continue
 		}
-		wt.HeadSHA, _ = GetHeadSHA(wt.Path)
-		wt.StatusChanged, wt.StatusUntracked, _ = GetWorktreeStatus(wt.Path)
 
-		if updated, e := runInDir(wt.Path, "log", "-1", "--format=%cr"); e == nil && updated != "" {
-			wt.UpdatedAt = updated
-		} else {
-			wt.UpdatedAt = "never"
+		if st, err := GetWorktreeStatus(wt.Path); err == nil {
+			wt.StatusChanged, wt.StatusUntracked = st.Changed, st.Untracked
+			wt.StatusStaged, wt.StatusUnstaged = st.Staged, st.Unstaged
 		}
+		wt.InProgressOp, _ = GetInProgressOp(wt.Path)
 
-		wt.Commits, _ = GetCommits(wt.Path)
+		if !lazy {
+			enrichWorktree(&wt, def)
+		}
 		worktrees = append(worktrees, wt)
 	}
+	flagSharedBranches(worktrees)
+	flagNameClashes(worktrees)
 	return worktrees, nil
 }
 
-// GetCommits returns the last 10 commits for the worktree at path.
-func GetCommits(worktreePath string) ([]types.Commit, error) {
-	out, err := runInDir(worktreePath, "log", "-10", "--format=%h|%s|%cr")
+// EnrichWorktree fills in the fields ListWorktrees skips in lazy mode:
+// branch status, HEAD, latest author and the recent commits.
+func EnrichWorktree(wt *types.Worktree, compare string) {
+	if compare == "" {
+		compare = getDefaultBranch()
+	}
+	enrichWorktree(wt, compare)
+}
+
+func enrichWorktree(wt *types.Worktree, def string) {
+	wt.Enriched = true
+	// Everything below needs at least one commit, and the directory.
+	if wt.Unborn || wt.Missing {
+		return
+	}
+
+	// Branch status and detail extras (skip for main worktree, and for
+	// whichever worktree has the default branch in a bare layout).
+	if !wt.IsMain && wt.Branch != def {
+		wt.Ahead, wt.Behind, wt.IsMerged, _ = GetBranchStatus(wt.Branch, def)
+		wt.UpstreamGone = IsUpstreamGone(wt.Branch)
+		wt.MergeBase, wt.ForkedAge, _ = GetMergeBaseInfo(wt.Branch, def)
+	}
+	wt.HeadSHA, _ = GetHeadSHA(wt.Path)
+	if wt.StatusChanged > 0 {
+		wt.DirtyAge, _ = GetDirtyAge(wt.Path)
+	}
+
+	if out, e := runInDir(wt.Path, "log", "-1", "--format=%cr%x00%an%x00%ae"); e == nil && out != "" {
+		parts := strings.SplitN(out, "\x00", 3)
+		wt.UpdatedAt = parts[0]
+		if len(parts) == 3 {
+			wt.LastAuthor, wt.LastAuthorEmail = parts[1], parts[2]
+		}
+	} else {
+		wt.UpdatedAt = "never"
+	}
+
+	wt.Commits, _ = GetCommits(wt.Path, "")
+}
+
+// flagSharedBranches fills SharedWith for worktrees that have the same branch
+// checked out. Git refuses to set that up, but manual .git surgery (or a
+// bug) can leave it behind, and the two then fight over the same ref.
+func flagSharedBranches(wts []types.Worktree) {
+	byBranch := make(map[string][]int)
+	for i, wt := range wts {
+		if strings.HasPrefix(wt.Branch, "(") { // (detached), (bare)
+			continue
+		}
+		byBranch[wt.Branch] = append(byBranch[wt.Branch], i)
+	}
+	for _, idx := range byBranch {
+		if len(idx) < 2 {
+			continue
+		}
+		for _, i := range idx {
+			for _, j := range idx {
+				if i != j {
+					wts[i].SharedWith = append(wts[i].SharedWith, wts[j].Path)
+				}
+			}
+		}
+	}
+}
+
+// flagNameClashes fills NameClashWith for worktrees listed under the same
+// display name, e.g. two branches given one meta.Name. Worktrees sharing a
+// branch are already flagged through SharedWith and left out.
+func flagNameClashes(wts []types.Worktree) {
+	byName := make(map[string][]int)
+	for i, wt := range wts {
+		byName[wt.Name] = append(byName[wt.Name], i)
+	}
+	for _, idx := range byName {
+		if len(idx) < 2 {
+			continue
+		}
+		for _, i := range idx {
+			for _, j := range idx {
+				if i != j && wts[i].Branch != wts[j].Branch {
+					wts[i].NameClashWith = append(wts[i].NameClashWith, wts[j].Path)
+				}
+			}
+		}
+	}
+}
+
+// MergeConflicts test-merges branch into def with merge-tree, which touches
+// neither refs nor any working tree, and returns the paths that would
+// conflict. It returns none when the branch merges cleanly. dir is any
+// worktree of the repo.
+func MergeConflicts(dir, branch, def string) ([]string, error) {
+	out, err := runInDirAllowing(dir, 1, "merge-tree", "--write-tree", "--name-only", "--no-messages", def, branch)
+	if err != nil {
+		return nil, fmt.Errorf("git merge-tree: %w", err)
+	}
+	// The first line is the resulting tree; conflicted paths follow.
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return lines[1:], nil
+}
+
+// MergeBranch merges branch into whatever is checked out in the worktree at
+// path, fast-forwarding when it can. A merge that stops part-way is aborted,
+// so the worktree is left as it was.
+func MergeBranch(path, branch string) error {
+	if _, err := runInDir(path, "merge", "--no-edit", branch); err != nil {
+		_, _ = runInDir(path, "merge", "--abort")
+		return err
+	}
+	return nil
+}
+
+// FetchBranch fetches just branch's upstream into the worktree at path. The
+// remote and remote branch come from branch.<name>.remote/merge, falling back
+// to origin and the same branch name.
+func FetchBranch(path, branch string) error {
+	remote, _ := runInDir(path, "config", "--get", "branch."+branch+".remote")
+	if remote == "" || remote == "." {
+		remote = "origin"
+	}
+	merge, _ := runInDir(path, "config", "--get", "branch."+branch+".merge")
+	if merge == "" {
+		merge = branch
+	}
+	_, err := runInDir(path, "fetch", remote, strings.TrimPrefix(merge, "refs/heads/"))
+	return err
+}
+
+// PushBranch pushes branch from the worktree at path to origin, setting it
+// as the upstream.
+func PushBranch(path, branch string) error {
+	_, err := runInDir(path, "push", "-u", "origin", branch)
+	return err
+}
+
+// FetchAll fetches every remote, pruning remote-tracking branches that no
+// longer exist.
+func FetchAll() error {
+	_, err := run("fetch", "--all", "--prune")
+	return err
+}
+
+// IsUpstreamGone reports whether branch has an upstream configured whose
+// remote branch no longer exists (git branch -vv shows "[origin/x: gone]").
+func IsUpstreamGone(branch string) bool {
+	out, err := run("for-each-ref", "--format=%(upstream:track)", "refs/heads/"+branch)
+	return err == nil && out == "[gone]"
+}
+
+// parseWorktreeList parses `git worktree list --porcelain -z` output. Each
+// attribute is NUL-terminated and worktrees are separated by an empty
+// attribute, so paths may contain any character, newlines included. The
+// first worktree is the main one.
+func parseWorktreeList(out string) []types.Worktree {
+	var worktrees []types.Worktree
+	var wt *types.Worktree
+	for _, attr := range strings.Split(out, "\x00") {
+		if attr == "" {
+			wt = nil // end of record
+			continue
+		}
+		if wt == nil {
+			worktrees = append(worktrees, types.Worktree{IsMain: len(worktrees) == 0})
+			wt = &worktrees[len(worktrees)-1]
+		}
+		key, value, _ := strings.Cut(attr, " ")
+		switch key {
+		case "worktree":
+			wt.Path = value
+		case "HEAD":
+			// git reports an all-zero object name when HEAD is unborn.
+			wt.Unborn = strings.Trim(value, "0") == ""
+		case "branch":
+			wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+			wt.Name = wt.Branch // default display name
+		case "detached":
+			wt.Branch = "(detached)"
+			wt.Name = "(detached)"
+		case "bare":
+			// Listed first like a main worktree, but with no checkout no
+			// worktree is the main one.
+			wt.Branch = "(bare)"
+			wt.Name = "(bare)"
+			wt.IsMain = false
+		case "locked":
+			wt.Locked = true
+		case "prunable":
+			wt.Prunable = true
+		}
+	}
+	for i := range worktrees {
+		if worktrees[i].Name == "" {
+			worktrees[i].Name = filepath.Base(worktrees[i].Path)
+		}
+	}
+	return worktrees
+}
+
+// GetCommits returns the last 10 commits for the worktree at path. With
+// since set, only commits not reachable from it are listed (git log
+// since..HEAD), e.g. a branch's own commits with since the default branch.
+func GetCommits(worktreePath, since string) ([]types.Commit, error) {
+	rev := "HEAD"
+	if since != "" {
+		rev = since + "..HEAD"
+	}
+	out, err := runInDir(worktreePath, "log", "-10", "--decorate=full", "--format=%h%x00%cr%x00%ct%x00%D%x00%s", rev, "--")
 	if err != nil || out == "" {
 		return nil, err
 	}
 	var commits []types.Commit
 	for _, line := range strings.Split(out, "\n") {
-		parts := strings.SplitN(line, "|", 3)
-		if len(parts) != 3 {
+		parts := strings.SplitN(line, "\x00", 5)
+		if len(parts) != 5 {
 			continue
 		}
+		ts, _ := strconv.ParseInt(parts[2], 10, 64)
+		var refs []string
+		if parts[3] != "" {
+			refs = strings.Split(parts[3], ", ")
+		}
 		commits = append(commits, types.Commit{
 			Hash:    parts[0],
-			Message: parts[1],
-			RelTime: parts[2],
+			RelTime: parts[1],
+			Time:    ts,
+			Refs:    refs,
+			Message: parts[4],
 		})
 	}
 	return commits, nil
 }
 
-// AddWorktree creates a new worktree with a new branch at wtPath.
-func AddWorktree(branch, wtPath string) error {
-	_, err := run("worktree", "add", "-b", branch, wtPath, "HEAD")
+// AddWorktree creates a new worktree with a new branch at wtPath, starting
+// from base (HEAD when empty). It returns what hooks such as post-checkout
+// printed; see addWorktree.
+func AddWorktree(branch, wtPath, base string) (string, error) {
+	return addWorktree(wtPath, AddWorktreeArgs(branch, wtPath, base)...)
+}
+
+// HookError is a git worktree add whose checkout went through but whose
+// post-checkout hook then failed: the worktree exists, its setup may not
+// be complete.
+type HookError struct {
+	Err error
+}
+
+func (e *HookError) Error() string { return "post-checkout hook failed: " + e.Err.Error() }
+
+func (e *HookError) Unwrap() error { return e.Err }
+
+// addWorktree runs a git worktree add and returns the hook output in its
+// combined stdout and stderr, that is, everything but git's own progress
+// lines. A failure that left the worktree behind is returned as a
+// *HookError; any other failure carries git's messages as the error and
+// returns no hook output.
+func addWorktree(wtPath string, args ...string) (string, error) {
+	dotGit := filepath.Join(wtPath, ".git")
+	existed := fileExists(dotGit)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err == nil {
+		return hookOutput(string(out)), nil
+	}
+	if !existed && fileExists(dotGit) {
+		return hookOutput(string(out)), &HookError{Err: err}
+	}
+	if msg := hookOutput(string(out)); msg != "" {
+		return "", fmt.Errorf("%s", msg)
+	}
+	return "", err
+}
+
+// gitAddProgress are the prefixes of the lines git worktree add prints
+// itself.
+var gitAddProgress = []string{"Preparing worktree", "HEAD is now at", "Updating files:", "branch '"}
+
+// hookOutput drops git's own progress lines from git worktree add output.
+func hookOutput(out string) string {
+	var kept []string
+	for _, line := range strings.FieldsFunc(out, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		own := false
+		for _, p := range gitAddProgress {
+			if strings.HasPrefix(line, p) {
+				own = true
+			}
+		}
+		if !own {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// AddWorktreeArgs returns the git arguments AddWorktree runs.
+func AddWorktreeArgs(branch, wtPath, base string) []string {
+	if base == "" {
+		base = "HEAD"
+	}
+	return []string{"worktree", "add", "-b", branch, wtPath, base}
+}
+
+// CheckBranchName returns an error if name is not a valid branch name.
+func CheckBranchName(name string) error {
+	if _, err := run("check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+	return nil
+}
+
+// CreateEmptyCommit records an empty commit with message in the worktree at
+// path, GPG/SSH-signing it when sign is set.
+func CreateEmptyCommit(path, message string, sign bool) error {
+	args := []string{"commit", "--allow-empty", "-m", message}
+	if sign {
+		args = append(args, "-S")
+	}
+	_, err := runInDir(path, args...)
 	return err
 }
 
+// SigningConfigured reports whether user.signingkey is set for the repo.
+func SigningConfigured() bool {
+	key, err := run("config", "--get", "user.signingkey")
+	return err == nil && key != ""
+}
+
+// LatestTag returns the most recent tag reachable from HEAD.
+func LatestTag() (string, error) {
+	return run("describe", "--tags", "--abbrev=0")
+}
+
+// RunShell runs command with sh -c in dir and returns its combined output.
+func RunShell(dir, command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = EnvFor(dir)
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// AddWorktreeForBranch checks out an existing local branch into a new
+// worktree at wtPath, returning hook output as AddWorktree does.
+func AddWorktreeForBranch(branch, wtPath string) (string, error) {
+	return addWorktree(wtPath, "worktree", "add", wtPath, branch)
+}
+
+// AddTrackingWorktree creates a local branch tracking remoteRef (e.g.
+// "origin/feat/x") and checks it out into a new worktree at wtPath,
+// returning hook output as AddWorktree does.
+func AddTrackingWorktree(remoteRef, branch, wtPath string) (string, error) {
+	return addWorktree(wtPath, "worktree", "add", "--track", "-b", branch, wtPath, remoteRef)
+}
+
+// BranchRef is a local or remote-tracking branch.
+type BranchRef struct {
+	Name   string // branch name without the remote prefix, e.g. "feat/x"
+	Remote string // remote name for remote-tracking refs, "" for local
+}
+
+// Ref returns the name git resolves, e.g. "feat/x" or "origin/feat/x".
+func (b BranchRef) Ref() string {
+	if b.Remote == "" {
+		return b.Name
+	}
+	return b.Remote + "/" + b.Name
+}
+
+// ListBranches returns all local branches followed by remote-tracking
+// branches (excluding symbolic refs such as origin/HEAD).
+func ListBranches() ([]BranchRef, error) {
+	out, err := run("for-each-ref", "--format=%(refname)%00%(symref)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+	var refs []BranchRef
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\x00", 2)
+		if len(parts) != 2 || parts[1] != "" {
+			continue // malformed or symbolic
+		}
+		switch name := parts[0]; {
+		case strings.HasPrefix(name, "refs/heads/"):
+			refs = append(refs, BranchRef{Name: strings.TrimPrefix(name, "refs/heads/")})
+		case strings.HasPrefix(name, "refs/remotes/"):
+			rest := strings.SplitN(strings.TrimPrefix(name, "refs/remotes/"), "/", 2)
+			if len(rest) == 2 {
+				refs = append(refs, BranchRef{Remote: rest[0], Name: rest[1]})
+			}
+		}
+	}
+	return refs, nil
+}
+
 // RemoveWorktree force-removes the worktree at path.
 func RemoveWorktree(path string) error {
 	_, err := run("worktree", "remove", "--force", path)
 	return err
 }
 
-// RenameBranch renames a branch in the current repository.
-func RenameBranch(oldName, newName string) error {
-	_, err := run("branch", "-m", oldName, newName)
-	return err
+// PruneWorktree drops git's record of the worktree at path, whose directory
+// is already gone. Unlike git worktree prune it leaves other stale entries
+// alone.
+func PruneWorktree(path string) error {
+	_, err := run("worktree", "remove", path)
+	return err
+}
+
+// TagExists reports whether refs/tags/<name> exists.
+func TagExists(name string) bool {
+	_, err := run("rev-parse", "--verify", "--quiet", "refs/tags/"+name)
+	return err == nil
+}
+
+// MoveWorktree relocates the worktree at from to the directory to, creating
+// any missing parent directories first.
+func MoveWorktree(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	_, err := run("worktree", "move", from, to)
+	return err
+}
+
+// ExpandPath resolves a user-entered path: a leading "~" becomes the home
+// directory and relative paths are taken relative to base.
+func ExpandPath(p, base string) string {
+	p = strings.TrimSpace(p)
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(base, p)
+	}
+	return filepath.Clean(p)
+}
+
+// ValidateMoveTarget checks that dest does not exist yet and that its nearest
+// existing ancestor is a writable directory.
+func ValidateMoveTarget(dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	dir := filepath.Dir(dest)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no existing parent directory for %s", dest)
+		}
+		dir = parent
+	}
+	probe, err := os.CreateTemp(dir, ".wt-probe-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// RenameBranch renames a branch in the current repository.
+func RenameBranch(oldName, newName string) error {
+	_, err := run("branch", "-m", oldName, newName)
+	return err
+}
+
+// ── Repo-global info ──────────────────────────────────────────────────────────
+
+// GetDefaultBranch exports the default-branch detection logic.
+func GetDefaultBranch() string { return getDefaultBranch() }
+
+// GetRemoteURL returns the origin remote URL shortened to "host/org/repo".
+func GetRemoteURL() (string, error) {
+	url, err := run("remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	return shortenURL(url), nil
+}
+
+func shortenURL(url string) string {
+	for _, pfx := range []string{"https://", "http://", "git@", "ssh://"} {
+		url = strings.TrimPrefix(url, pfx)
+	}
+	url = strings.Replace(url, ":", "/", 1) // git@github.com:org/repo → github.com/org/repo
+	url = strings.TrimSuffix(url, ".git")
+	return url
+}
+
+// WebURL turns a remote URL (https, ssh or scp-style) into the https address
+// of the repository's web page. ok is false for hosts it doesn't recognise.
+func WebURL(remote string) (url string, ok bool) {
+	u := remote
+	if rest, found := strings.CutPrefix(u, "ssh://"); found {
+		u = rest
+		// ssh://git@host:2222/org/repo — the port means nothing over https.
+		if host, path, found := strings.Cut(u, "/"); found {
+			if h, _, hasPort := strings.Cut(host, ":"); hasPort {
+				host = h
+			}
+			u = host + "/" + path
+		}
+	}
+	u = shortenURL(u)
+	if _, after, found := strings.Cut(u, "@"); found { // user@host/… from https
+		u = after
+	}
+	host, _, _ := strings.Cut(u, "/")
+	switch {
+	case host == "github.com", host == "gitlab.com", host == "bitbucket.org",
+		strings.Contains(host, "github"), strings.Contains(host, "gitlab"):
+		return "https://" + u, true
+	}
+	return "", false
+}
+
+// CommitWebURL returns the web page for commit sha on origin, as seen from
+// the worktree at path. The error says why when there is no such page.
+func CommitWebURL(worktreePath, sha string) (string, error) {
+	remote, err := runInDir(worktreePath, "remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	base, ok := WebURL(remote)
+	if !ok {
+		return "", fmt.Errorf("no web URL known for remote %s", remote)
+	}
+	full, err := ResolveCommit(worktreePath, sha)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.Contains(base, "gitlab"):
+		return base + "/-/commit/" + full, nil
+	case strings.Contains(base, "bitbucket.org"):
+		return base + "/commits/" + full, nil
+	}
+	return base + "/commit/" + full, nil
+}
+
+// ResolveCommit expands a commit-ish to its full SHA.
+func ResolveCommit(worktreePath, rev string) (string, error) {
+	return runInDir(worktreePath, "rev-parse", "--verify", rev+"^{commit}")
+}
+
+// GetStashCount returns the number of stash entries.
+func GetStashCount() (int, error) {
+	out, err := run("stash", "list")
+	if err != nil || strings.TrimSpace(out) == "" {
+		return 0, nil
+	}
+	return len(strings.Split(strings.TrimSpace(out), "\n")), nil
+}
+
+// StashEntry is one entry of git stash list.
+type StashEntry struct {
+	Ref     string // e.g. "stash@{0}"
+	Hash    string // short SHA of the stash commit
+	RelTime string // e.g. "3 hours ago"
+	Subject string // e.g. "WIP on main: 1a2b3c4 fix parser"
+}
+
+// ListStashes returns the stash entries, newest first.
+func ListStashes() ([]StashEntry, error) {
+	out, err := run("stash", "list", "--format=%gd%x00%h%x00%cr%x00%gs")
+	if err != nil {
+		return nil, err
+	}
+	var entries []StashEntry
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		entries = append(entries, StashEntry{Ref: parts[0], Hash: parts[1], RelTime: parts[2], Subject: parts[3]})
+	}
+	return entries, nil
+}
+
+// ApplyStash applies the stash entry ref to the worktree at path, keeping
+// the entry. With pop set, the entry is dropped once it applies cleanly.
+func ApplyStash(path, ref string, pop bool) error {
+	verb := "apply"
+	if pop {
+		verb = "pop"
+	}
+	_, err := runInDir(path, "stash", verb, ref)
+	return err
+}
+
+// DropStash deletes the stash entry ref.
+func DropStash(ref string) error {
+	_, err := run("stash", "drop", ref)
+	return err
+}
+
+// GetStashDetail returns the changes the stash entry ref holds against the
+// commit it was made on, shaped like a CommitDetail for the diff overlay.
+func GetStashDetail(worktreePath, ref string, opts DiffOptions) (*types.CommitDetail, error) {
+	head, err := runInDir(worktreePath, "show", ref, "--no-patch", "--pretty=format:%h%x00%s%x00%cr")
+	if err != nil {
+		return nil, err
+	}
+	filesOut, _ := runInDir(worktreePath, "stash", "show", "--name-status", ref)
+	diffOut, _ := runInDir(worktreePath, append([]string{"stash", "show", "--patch", "--no-color"}, append(opts.args(), ref)...)...)
+	detail := &types.CommitDetail{
+		Files:  parseNameStatus(filesOut),
+		Diff:   parseDiff(diffOut),
+		Loaded: true,
+	}
+	if parts := strings.Split(head, "\x00"); len(parts) == 3 {
+		detail.ShortHash, detail.Subject, detail.RelTime = parts[0], parts[1], parts[2]
+	}
+	return detail, nil
+}
+
+// GetFetchedAgo returns a human-readable relative time since the last fetch,
+// or ("", nil) if FETCH_HEAD does not exist.
+func GetFetchedAgo() (string, error) {
+	// Ask git where FETCH_HEAD lives: the git dir may be a linked
+	// worktree's admin dir or relocated with GIT_DIR.
+	fetchHead, err := run("rev-parse", "--path-format=absolute", "--git-path", "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(fetchHead)
+	if err != nil {
+		return "", nil // not an error — just hasn't been fetched yet
+	}
+	return fmtDuration(time.Since(info.ModTime())), nil
+}
+
+func fmtDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "just now"
+	}
+	return FormatAge(d) + " ago"
+}
+
+// FormatAge renders a duration in its largest whole unit, e.g. "3d" or "5h".
+func FormatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// ── Per-worktree detail extras ────────────────────────────────────────────────
+
+// GetHeadSHA returns the short SHA of HEAD for the worktree at path.
+func GetHeadSHA(worktreePath string) (string, error) {
+	return runInDir(worktreePath, "rev-parse", "--short", "HEAD")
+}
+
+// WorktreeStatus counts the entries of git status --porcelain. A file with
+// both staged and unstaged changes counts towards Staged and Unstaged.
+type WorktreeStatus struct {
+	Changed   int // tracked files with any change
+	Staged    int // files with changes in the index
+	Unstaged  int // files with changes in the working tree
+	Untracked int
+}
+
+// GetWorktreeStatus returns counts of changed and untracked files.
+func GetWorktreeStatus(worktreePath string) (WorktreeStatus, error) {
+	var st WorktreeStatus
+	out, err := runInDirRaw(worktreePath, "status", "--porcelain")
+	if err != nil {
+		return st, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		if strings.HasPrefix(line, "??") {
+			st.Untracked++
+			continue
+		}
+		st.Changed++
+		if line[0] != ' ' {
+			st.Staged++
+		}
+		if line[1] != ' ' {
+			st.Unstaged++
+		}
+	}
+	return st, nil
+}
+
+// StatusFile is one entry of git status --porcelain.
+type StatusFile struct {
+	Code string // the two-letter XY code, e.g. " M", "A ", "??"
+	Path string // for renames, the new path
+}
+
+// Untracked reports whether the entry is an untracked file or directory.
+func (f StatusFile) Untracked() bool { return f.Code == "??" }
+
+// GetWorktreeFiles returns the entries of git status --porcelain for the
+// worktree at path: the files behind GetWorktreeStatus's counts. Untracked
+// directories are listed as a single "dir/" entry, as git shows them.
+func GetWorktreeFiles(worktreePath string) ([]StatusFile, error) {
+	out, err := runInDirRaw(worktreePath, "status", "--porcelain", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []StatusFile
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		if len(e) < 4 {
+			continue
+		}
+		files = append(files, StatusFile{Code: e[:2], Path: e[3:]})
+		// -z puts a rename's original path in the next entry.
+		if e[0] == 'R' || e[0] == 'C' {
+			i++
+		}
+	}
+	return files, nil
+}
+
+// ListFiles returns the paths of the worktree at path that git would show:
+// tracked files plus untracked ones not ignored, relative to its root.
+func ListFiles(worktreePath string) ([]string, error) {
+	out, err := runInDirRaw(worktreePath, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// StageAll stages every change in the worktree, untracked files included.
+func StageAll(worktreePath string) error {
+	_, err := runInDir(worktreePath, "add", "-A")
+	return err
+}
+
+// UnstageAll resets the index to HEAD, keeping working-tree changes.
+func UnstageAll(worktreePath string) error {
+	_, err := runInDir(worktreePath, "reset", "-q")
+	return err
+}
+
+// GetDirtyAge returns how long the tracked changes in a worktree have sat
+// untouched, e.g. "3d", based on the newest mtime among modified files. It
+// falls back to the index mtime when no modified file can be stat'ed (for
+// example when every change is a deletion). Returns "" for a clean tree.
+func GetDirtyAge(worktreePath string) (string, error) {
+	out, err := runInDirRaw(worktreePath, "status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	var newest time.Time
+	dirty := false
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 || strings.HasPrefix(line, "??") {
+			continue
+		}
+		dirty = true
+		file := line[3:]
+		if i := strings.Index(file, " -> "); i != -1 {
+			file = file[i+4:] // rename: "old -> new"
+		}
+		if unq, e := strconv.Unquote(file); e == nil {
+			file = unq
+		}
+		if info, e := os.Stat(filepath.Join(worktreePath, file)); e == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	if !dirty {
+		return "", nil
+	}
+	if newest.IsZero() {
+		index, e := runInDir(worktreePath, "rev-parse", "--git-path", "index")
+		if e != nil {
+			return "", e
+		}
+		if !filepath.IsAbs(index) {
+			index = filepath.Join(worktreePath, index)
+		}
+		info, e := os.Stat(index)
+		if e != nil {
+			return "", e
+		}
+		newest = info.ModTime()
+	}
+	return FormatAge(time.Since(newest)), nil
+}
+
+// inProgressMarkers maps gitdir marker files to the operation they signal,
+// checked in order.
+var inProgressMarkers = []struct{ path, op string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
 }
 
-// ── Repo-global info ──────────────────────────────────────────────────────────
-
-// GetDefaultBranch exports the default-branch detection logic.
-func GetDefaultBranch() string { return getDefaultBranch() }
-
-// GetRemoteURL returns the origin remote URL shortened to "host/org/repo".
-func GetRemoteURL() (string, error) {
-	url, err := run("remote", "get-url", "origin")
+// GetInProgressOp returns the name of an unfinished git operation in the
+// worktree ("rebase", "merge", "cherry-pick", "revert", "bisect"), or "".
+func GetInProgressOp(worktreePath string) (string, error) {
+	args := []string{"rev-parse"}
+	for _, mk := range inProgressMarkers {
+		args = append(args, "--git-path", mk.path)
+	}
+	out, err := runInDir(worktreePath, args...)
 	if err != nil {
 		return "", err
 	}
-	return shortenURL(url), nil
-}
-
-func shortenURL(url string) string {
-	for _, pfx := range []string{"https://", "http://", "git@", "ssh://"} {
-		url = strings.TrimPrefix(url, pfx)
+	for i, p := range strings.Split(out, "\n") {
+		if i >= len(inProgressMarkers) {
+			break
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(worktreePath, p)
+		}
+		if _, err := os.Stat(p); err == nil {
+			return inProgressMarkers[i].op, nil
+		}
 	}
-	url = strings.Replace(url, ":", "/", 1) // git@github.com:org/repo → github.com/org/repo
-	url = strings.TrimSuffix(url, ".git")
-	return url
+	return "", nil
 }
 
-// GetStashCount returns the number of stash entries.
-func GetStashCount() (int, error) {
-	out, err := run("stash", "list")
-	if err != nil || strings.TrimSpace(out) == "" {
-		return 0, nil
-	}
-	return len(strings.Split(strings.TrimSpace(out), "\n")), nil
+// WorktreeLink describes how a worktree is wired to its repository: the .git
+// file in the worktree and the admin directory under .git/worktrees it names.
+type WorktreeLink struct {
+	DotGit     string   // contents of <path>/.git; "" when it is a directory (main worktree)
+	AdminDir   string   // the gitdir named in .git, made absolute
+	BackLink   string   // contents of <admin>/gitdir, which should name <path>/.git
+	Head       string   // contents of <admin>/HEAD
+	LockReason string   // contents of <admin>/locked, when the worktree is locked
+	Problems   []string // inconsistencies found while following the links
 }
 
-// GetFetchedAgo returns a human-readable relative time since the last fetch,
-// or ("", nil) if FETCH_HEAD does not exist.
-func GetFetchedAgo() (string, error) {
-	root, err := GetRepoRoot()
+// GetWorktreeLink follows the .git file of the worktree at path to its admin
+// directory and back, noting anything broken along the way.
+func GetWorktreeLink(path string) WorktreeLink {
+	var l WorktreeLink
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
 	if err != nil {
-		return "", err
+		l.Problems = append(l.Problems, dotGit+" is missing")
+		return l
 	}
-	info, err := os.Stat(filepath.Join(root, ".git", "FETCH_HEAD"))
-	if err != nil {
-		return "", nil // not an error — just hasn't been fetched yet
+	if info.IsDir() {
+		l.AdminDir = dotGit
+		l.Head = readTrimmed(filepath.Join(dotGit, "HEAD"))
+		return l
 	}
-	return fmtDuration(time.Since(info.ModTime())), nil
-}
 
-func fmtDuration(d time.Duration) string {
-	switch {
-	case d < time.Minute:
-		return "just now"
-	case d < time.Hour:
-		return fmt.Sprintf("%dm ago", int(d.Minutes()))
-	case d < 24*time.Hour:
-		return fmt.Sprintf("%dh ago", int(d.Hours()))
-	default:
-		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	l.DotGit = readTrimmed(dotGit)
+	gitdir, ok := strings.CutPrefix(l.DotGit, "gitdir: ")
+	if !ok {
+		l.Problems = append(l.Problems, ".git file has no \"gitdir: \" line")
+		return l
+	}
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(path, gitdir)
+	}
+	l.AdminDir = filepath.Clean(gitdir)
+	if _, err := os.Stat(l.AdminDir); err != nil {
+		l.Problems = append(l.Problems, "admin dir does not exist — the repo may have moved or been pruned")
+		return l
 	}
-}
-
-// ── Per-worktree detail extras ────────────────────────────────────────────────
 
-// GetHeadSHA returns the short SHA of HEAD for the worktree at path.
-func GetHeadSHA(worktreePath string) (string, error) {
-	return runInDir(worktreePath, "rev-parse", "--short", "HEAD")
+	l.BackLink = readTrimmed(filepath.Join(l.AdminDir, "gitdir"))
+	if l.BackLink == "" {
+		l.Problems = append(l.Problems, "admin dir has no gitdir file")
+	} else if !samePath(l.BackLink, dotGit) {
+		l.Problems = append(l.Problems, "admin dir points back to a different location — git worktree repair fixes this")
+	}
+	l.Head = readTrimmed(filepath.Join(l.AdminDir, "HEAD"))
+	if _, err := os.Stat(filepath.Join(l.AdminDir, "locked")); err == nil {
+		l.LockReason = readTrimmed(filepath.Join(l.AdminDir, "locked"))
+		if l.LockReason == "" {
+			l.LockReason = "(no reason given)"
+		}
+	}
+	return l
 }
 
-// GetWorktreeStatus returns counts of changed and untracked files.
-func GetWorktreeStatus(worktreePath string) (changed, untracked int, err error) {
-	out, err := runInDir(worktreePath, "status", "--porcelain")
+// readTrimmed returns the trimmed contents of a file, or "" if unreadable.
+func readTrimmed(p string) string {
+	data, err := os.ReadFile(p)
 	if err != nil {
-		return 0, 0, err
+		return ""
 	}
-	for _, line := range strings.Split(out, "\n") {
-		if len(line) < 2 {
-			continue
-		}
-		if strings.HasPrefix(line, "??") {
-			untracked++
-		} else {
-			changed++
-		}
+	return strings.TrimSpace(string(data))
+}
+
+// samePath reports whether a and b name the same file once symlinks are
+// resolved (e.g. /tmp vs /private/tmp on macOS).
+func samePath(a, b string) bool {
+	if ra, err := filepath.EvalSymlinks(a); err == nil {
+		a = ra
+	}
+	if rb, err := filepath.EvalSymlinks(b); err == nil {
+		b = rb
 	}
-	return changed, untracked, nil
+	return filepath.Clean(a) == filepath.Clean(b)
 }
 
 // ── PR badge (gh CLI) ─────────────────────────────────────────────────────────
@@ -319,43 +1343,291 @@ func IsGHAvailable() bool {
 // PR exists, gh is unavailable, or the call fails.
 func GetPRInfo(branch string) (*types.PRInfo, error) {
 	out, err := exec.Command("gh", "pr", "view", branch,
-		"--json", "state,number,url").Output()
+		"--json", "state,number,url,reviewDecision").Output()
 	if err != nil {
-		return nil, nil // no PR or gh not available
-	}
-	var v struct {
-		State  string `json:"state"`
-		Number int    `json:"number"`
-		URL    string `json:"url"`
+		// view misses PRs from forks or renamed branches; search by head ref.
+		return findPRByHead(branch), nil
 	}
+	var v prJSON
 	if err := json.Unmarshal(out, &v); err != nil {
 		return nil, nil
 	}
-	return &types.PRInfo{State: v.State, Number: v.Number, URL: v.URL}, nil
+	return &types.PRInfo{State: v.State, Number: v.Number, URL: v.URL, ReviewState: v.ReviewDecision}, nil
+}
+
+type prJSON struct {
+	State          string `json:"state"`
+	Number         int    `json:"number"`
+	URL            string `json:"url"`
+	ReviewDecision string `json:"reviewDecision"`
+}
+
+// findPRByHead looks up PRs whose head ref is branch, preferring an open one
+// over the most recent closed or merged one. It returns nil when none exist
+// or gh fails.
+func findPRByHead(branch string) *types.PRInfo {
+	out, err := exec.Command("gh", "pr", "list", "--head", branch, "--state", "all",
+		"--json", "state,number,url,reviewDecision").Output()
+	if err != nil {
+		return nil
+	}
+	var prs []prJSON
+	if err := json.Unmarshal(out, &prs); err != nil || len(prs) == 0 {
+		return nil
+	}
+	best := prs[0] // gh lists newest first
+	for _, p := range prs {
+		if p.State == "OPEN" {
+			best = p
+			break
+		}
+	}
+	return &types.PRInfo{State: best.State, Number: best.Number, URL: best.URL, ReviewState: best.ReviewDecision}
+}
+
+// CreatePR opens a pull request for branch with gh, run from the worktree at
+// path, and returns it as GetPRInfo would. A draft PR is opened with
+// --draft. gh's own error message is returned on failure.
+func CreatePR(path, branch, title, body string, draft bool) (*types.PRInfo, error) {
+	args := []string{"pr", "create", "--head", branch, "--title", title, "--body", body}
+	if draft {
+		args = append(args, "--draft")
+	}
+	cmd := exec.Command("gh", args...)
+	cmd.Dir = path
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	if info, _ := GetPRInfo(branch); info != nil {
+		return info, nil
+	}
+	// gh prints the new PR's URL, which ends in its number.
+	url := strings.TrimSpace(string(out))
+	n, _ := strconv.Atoi(url[strings.LastIndex(url, "/")+1:])
+	return &types.PRInfo{State: "OPEN", Number: n, URL: url}, nil
+}
+
+// PRSummary is one open pull request as listed by ListOpenPRs.
+type PRSummary struct {
+	Number int
+	Title  string
+	Branch string // head branch name
+	Author string
+	Fork   bool // the head branch lives in another repository
+}
+
+// ListOpenPRs returns the open PRs of the GitHub repo gh resolves for the
+// current directory, newest first.
+func ListOpenPRs() ([]PRSummary, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("gh", "pr", "list", "--state", "open", "--limit", "100",
+		"--json", "number,title,headRefName,author,isCrossRepository")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	var prs []struct {
+		Number      int    `json:"number"`
+		Title       string `json:"title"`
+		HeadRefName string `json:"headRefName"`
+		Author      struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		IsCrossRepository bool `json:"isCrossRepository"`
+	}
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return nil, err
+	}
+	list := make([]PRSummary, 0, len(prs))
+	for _, p := range prs {
+		list = append(list, PRSummary{Number: p.Number, Title: p.Title, Branch: p.HeadRefName,
+			Author: p.Author.Login, Fork: p.IsCrossRepository})
+	}
+	return list, nil
+}
+
+// LocalBranch returns the local branch a PR is checked out as: its head
+// branch, or pr/<number> for one from a fork, whose branch name may clash
+// with ours.
+func (p PRSummary) LocalBranch() string {
+	if p.Fork {
+		return fmt.Sprintf("pr/%d", p.Number)
+	}
+	return p.Branch
+}
+
+// AddPRWorktree checks pr out into a new worktree at wtPath, as gh pr
+// checkout would but without touching the current worktree. A PR from this
+// repo gets a local branch tracking origin's; one from a fork is fetched
+// from origin's pull/<n>/head. Hook output is returned as AddWorktree does.
+func AddPRWorktree(pr PRSummary, wtPath string) (string, error) {
+	branch := pr.LocalBranch()
+	_, err := run("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	exists := err == nil
+	switch {
+	case pr.Fork:
+		// Updates an existing pr/<n> too, if it fast-forwards.
+		if _, err := run("fetch", "origin", fmt.Sprintf("pull/%d/head:%s", pr.Number, branch)); err != nil {
+			return "", err
+		}
+	default:
+		if _, err := run("fetch", "origin", pr.Branch); err != nil {
+			return "", err
+		}
+		if !exists {
+			return AddTrackingWorktree("origin/"+pr.Branch, branch, wtPath)
+		}
+	}
+	return AddWorktreeForBranch(branch, wtPath)
+}
+
+// IsBranchProtected reports whether branch has protection rules on the
+// GitHub repo gh resolves for the current directory. A branch missing from
+// the remote, or a failed call, counts as unprotected.
+func IsBranchProtected(branch string) bool {
+	out, err := exec.Command("gh", "api", "repos/{owner}/{repo}/branches/"+branch,
+		"--jq", ".protected").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// DiffOptions tweaks how patches are generated for the commit overlay.
+type DiffOptions struct {
+	Algorithm        string // --diff-algorithm value; "" uses git's configured default
+	IgnoreWhitespace bool   // --ignore-all-space
+}
+
+// args returns the git show flags for these options.
+func (o DiffOptions) args() []string {
+	var args []string
+	if o.Algorithm != "" {
+		args = append(args, "--diff-algorithm="+o.Algorithm)
+	}
+	if o.IgnoreWhitespace {
+		args = append(args, "--ignore-all-space")
+	}
+	return args
 }
 
 // GetCommitDetail fetches full commit data (subject, body, files changed, diff)
 // for the given short or full SHA in the worktree at worktreePath.
-func GetCommitDetail(worktreePath, sha string) (*types.CommitDetail, error) {
+func GetCommitDetail(worktreePath, sha string, opts DiffOptions) (*types.CommitDetail, error) {
 	shortHash, _ := runInDir(worktreePath, "show", sha, "--no-patch", "--pretty=format:%h")
 	subject, _ := runInDir(worktreePath, "show", sha, "--no-patch", "--pretty=format:%s")
 	body, _ := runInDir(worktreePath, "show", sha, "--no-patch", "--pretty=format:%b")
 	relTime, _ := runInDir(worktreePath, "show", sha, "--no-patch", "--pretty=format:%cr")
+	people, _ := runInDir(worktreePath, "show", sha, "--no-patch", "--pretty=format:%an%x00%cn%x00%G?")
+	parents, _ := runInDir(worktreePath, "show", sha, "--no-patch", "--pretty=format:%P")
 
-	// --pretty=format: (empty) suppresses the commit header so we get just the list.
-	filesOut, _ := runInDir(worktreePath, "show", sha, "--name-status", "--no-patch", "--pretty=format:")
-	diffOut, _ := runInDir(worktreePath, "show", sha, "--patch", "--no-color", "--pretty=format:")
+	// --pretty=format: (empty) suppresses the commit header so we get just the
+	// list. (--no-patch is not needed, and newer git rejects it with --name-status.)
+	filesOut, _ := runInDir(worktreePath, "show", sha, "--name-status", "--pretty=format:")
+	diffArgs := append([]string{"show", sha, "--patch", "--no-color", "--pretty=format:"}, opts.args()...)
+	diffOut, _ := runInDir(worktreePath, diffArgs...)
 
 	detail := &types.CommitDetail{
 		ShortHash: shortHash,
 		Subject:   subject,
 		Body:      strings.TrimRight(body, "\r\n"),
 		RelTime:   relTime,
+		Root:      parents == "",
 		Loaded:    true,
 	}
+	if parts := strings.Split(people, "\x00"); len(parts) == 3 {
+		detail.Author = parts[0]
+		detail.Committer = parts[1]
+		detail.Signature = parts[2]
+	}
+
+	detail.Files = parseNameStatus(filesOut)
+	detail.Diff = parseDiff(diffOut)
+	return detail, nil
+}
+
+// GetWorkingDiff returns the uncommitted changes (staged and unstaged,
+// against HEAD) of the worktree at path, shaped like a CommitDetail so the
+// commit overlay can render it.
+func GetWorkingDiff(worktreePath string, opts DiffOptions) (*types.CommitDetail, error) {
+	filesOut, err := runInDir(worktreePath, "diff", "HEAD", "--name-status")
+	if err != nil {
+		return nil, err
+	}
+	diffOut, err := runInDir(worktreePath, append([]string{"diff", "HEAD", "--patch", "--no-color"}, opts.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	staged, _ := runInDir(worktreePath, "diff", "--cached", "--name-only")
+	unstaged, _ := runInDir(worktreePath, "diff", "--name-only")
+	inStaged := lineSet(staged)
+	inUnstaged := lineSet(unstaged)
+	files := parseNameStatus(filesOut)
+	for i := range files {
+		files[i].Staged = inStaged[files[i].Path]
+		files[i].Unstaged = inUnstaged[files[i].Path]
+	}
+	return &types.CommitDetail{
+		Subject: "Uncommitted changes",
+		Files:   files,
+		Diff:    parseDiff(diffOut),
+		Loaded:  true,
+	}, nil
+}
+
+// ShowFileAtRev returns file's contents as of rev (git show rev:file), with
+// file relative to the repo root as diffs name it.
+func ShowFileAtRev(worktreePath, rev, file string) (string, error) {
+	return runInDirRaw(worktreePath, "show", rev+":"+file)
+}
+
+// Commit records the staged changes in the worktree at path.
+func Commit(worktreePath, message string) error {
+	_, err := runInDir(worktreePath, "commit", "-m", message)
+	return err
+}
+
+// CommitAll stages every tracked change (git commit -a) and commits it.
+func CommitAll(worktreePath, message string) error {
+	_, err := runInDir(worktreePath, "commit", "-a", "-m", message)
+	return err
+}
+
+// StageFile adds file's working-tree changes to the index.
+func StageFile(worktreePath, file string) error {
+	_, err := runInDir(worktreePath, "add", "--", file)
+	return err
+}
+
+// UnstageFile resets file in the index back to HEAD, keeping the
+// working-tree changes.
+func UnstageFile(worktreePath, file string) error {
+	_, err := runInDir(worktreePath, "reset", "-q", "HEAD", "--", file)
+	return err
+}
+
+// lineSet returns the non-empty lines of s as a set.
+func lineSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, l := range strings.Split(s, "\n") {
+		if l != "" {
+			set[l] = true
+		}
+	}
+	return set
+}
 
-	// Parse file status list.
-	for _, line := range strings.Split(filesOut, "\n") {
+// parseNameStatus parses --name-status output into file entries.
+func parseNameStatus(out string) []types.CommitFile {
+	var files []types.CommitFile
+	for _, line := range strings.Split(out, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
@@ -370,11 +1642,18 @@ func GetCommitDetail(worktreePath, sha string) (*types.CommitDetail, error) {
 			status = string(status[0])
 		}
 		path := parts[len(parts)-1] // for renames the new path is last
-		detail.Files = append(detail.Files, types.CommitFile{Status: status, Path: path})
+		files = append(files, types.CommitFile{Status: status, Path: path})
 	}
+	return files
+}
 
-	// Parse diff output line by line.
-	for _, line := range strings.Split(diffOut, "\n") {
+// parseDiff categorises patch output line by line.
+func parseDiff(out string) []types.DiffLine {
+	if out == "" {
+		return nil
+	}
+	var lines []types.DiffLine
+	for _, line := range strings.Split(out, "\n") {
 		var dt string
 		switch {
 		case strings.HasPrefix(line, "diff --git"):
@@ -394,15 +1673,14 @@ func GetCommitDetail(worktreePath, sha string) (*types.CommitDetail, error) {
 		default:
 			dt = " "
 		}
-		detail.Diff = append(detail.Diff, types.DiffLine{Type: dt, Content: line})
+		lines = append(lines, types.DiffLine{Type: dt, Content: line})
 	}
-
-	return detail, nil
+	return lines
 }
 
-// SaveWorktreeMeta stores user-defined metadata for a worktree.
-// It captures the current HEAD SHA as the createdFrom commit.
-func SaveWorktreeMeta(branch, name, description string) error {
+// SaveWorktreeMeta stores user-defined metadata for a worktree, with
+// createdFrom as the short SHA its branch started at ("" when unknown).
+func SaveWorktreeMeta(branch, name, description, createdFrom string) error {
 	root, err := GetRepoRoot()
 	if err != nil {
 		return err
@@ -411,11 +1689,10 @@ func SaveWorktreeMeta(branch, name, description string) error {
 	if meta == nil {
 		meta = make(map[string]WorktreeMeta)
 	}
-	head, _ := run("rev-parse", "--short", "HEAD")
 	meta[branch] = WorktreeMeta{
 		Name:        name,
 		Description: description,
-		CreatedFrom: head,
+		CreatedFrom: createdFrom,
 	}
 	return writeMeta(root, meta)
 }
@@ -434,17 +1711,107 @@ func DeleteWorktreeMeta(branch string) error {
 	return writeMeta(root, meta)
 }
 
+// GetWorktreeMeta returns the stored metadata for a branch and whether an
+// entry exists.
+func GetWorktreeMeta(branch string) (WorktreeMeta, bool) {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return WorktreeMeta{}, false
+	}
+	meta, _ := readMeta(root)
+	m, ok := meta[branch]
+	return m, ok
+}
+
+// SetWorktreeMeta replaces the metadata entry for a branch as-is.
+func SetWorktreeMeta(branch string, m WorktreeMeta) error {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	meta, _ := readMeta(root)
+	if meta == nil {
+		meta = make(map[string]WorktreeMeta)
+	}
+	meta[branch] = m
+	return writeMeta(root, meta)
+}
+
+// RenameWorktreeMeta moves the metadata entry and scratchpad from oldBranch
+// to newBranch so they follow a branch rename.
+func RenameWorktreeMeta(oldBranch, newBranch string) error {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	if from := scratchpadPath(root, oldBranch); fileExists(from) {
+		to := scratchpadPath(root, newBranch)
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	meta, _ := readMeta(root)
+	m, ok := meta[oldBranch]
+	if !ok {
+		return nil
+	}
+	delete(meta, oldBranch)
+	meta[newBranch] = m
+	return writeMeta(root, meta)
+}
+
+// ScratchpadPath returns where branch's markdown scratchpad lives:
+// notes/<branch>.md in worktree-tui's directory, a slash in the branch
+// nesting a directory. The file need not exist yet.
+func ScratchpadPath(branch string) (string, error) {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return scratchpadPath(root, branch), nil
+}
+
+func scratchpadPath(repoRoot, branch string) string {
+	return filepath.Join(toolDir(repoRoot), "notes", filepath.FromSlash(branch)+".md")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 // --- Metadata persistence ---
 
 // WorktreeMeta is the user-defined metadata persisted per branch.
 type WorktreeMeta struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	CreatedFrom string `json:"createdFrom"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	CreatedFrom string   `json:"createdFrom"`
+	Notes       string   `json:"notes,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// toolDir is where worktree-tui keeps its per-repo files: in the git
+// directory all worktrees share, which git reports wherever it is (a bare
+// repo, or a directory relocated with GIT_DIR). Launched from a linked
+// worktree, that is still the main repo's .git, never the linked one's
+// .git file. When the current directory gives no answer, repoRoot is asked;
+// <root>/.git is only the last resort.
+func toolDir(repoRoot string) string {
+	if common, err := GetCommonDir(); err == nil {
+		return filepath.Join(common, "worktree-tui")
+	}
+	if common, err := runInDir(repoRoot, "rev-parse", "--path-format=absolute", "--git-common-dir"); err == nil && common != "" {
+		return filepath.Join(common, "worktree-tui")
+	}
+	return filepath.Join(repoRoot, ".git", "worktree-tui")
 }
 
 func metaFilePath(repoRoot string) string {
-	return filepath.Join(repoRoot, ".git", "worktree-tui", "meta.json")
+	return filepath.Join(toolDir(repoRoot), "meta.json")
 }
 
 func readMeta(repoRoot string) (map[string]WorktreeMeta, error) {
@@ -471,6 +1838,53 @@ func writeMeta(repoRoot string, meta map[string]WorktreeMeta) error {
 	return os.WriteFile(p, data, 0o644)
 }
 
+// --- Per-repo UI state ---
+
+// RepoState is UI state persisted per repo, separate from per-branch metadata.
+type RepoState struct {
+	Pinned       []string         `json:"pinned,omitempty"`       // pinned branches, in pin order
+	LastAccessed map[string]int64 `json:"lastAccessed,omitempty"` // branch → Unix time last focused or cd'd into
+	SortMode     string           `json:"sortMode,omitempty"`     // worktree list order; "" is git's order
+}
+
+func stateFilePath(repoRoot string) string {
+	return filepath.Join(toolDir(repoRoot), "state.json")
+}
+
+// LoadRepoState reads the repo's UI state; a missing file yields the zero value.
+func LoadRepoState() (RepoState, error) {
+	var st RepoState
+	root, err := GetRepoRoot()
+	if err != nil {
+		return st, err
+	}
+	data, err := os.ReadFile(stateFilePath(root))
+	if err != nil {
+		return st, nil
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return RepoState{}, nil
+	}
+	return st, nil
+}
+
+// SaveRepoState writes the repo's UI state.
+func SaveRepoState(st RepoState) error {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	p := stateFilePath(root)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
 // --- Shell integration ---
 
 const cdTempFile = "/tmp/.wt_cd_path"
@@ -505,24 +1919,45 @@ func MarkShellIntegrated() error {
 	return os.WriteFile(p, []byte("1"), 0o644)
 }
 
-// SetupShellIntegration appends the wt() wrapper to the user's shell rc file.
-func SetupShellIntegration() error {
+// shellMarker heads the wt() wrapper in the user's rc file.
+const shellMarker = "# worktree-tui shell integration"
+
+// shellRCFile returns the rc file of the user's $SHELL.
+func shellRCFile() (string, error) {
 	shell := os.Getenv("SHELL")
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return "", err
 	}
-	var rcFile string
 	switch {
 	case strings.Contains(shell, "zsh"):
-		rcFile = filepath.Join(home, ".zshrc")
+		return filepath.Join(home, ".zshrc"), nil
 	case strings.Contains(shell, "bash"):
-		rcFile = filepath.Join(home, ".bashrc")
-	default:
-		return fmt.Errorf("unsupported shell: %s", shell)
+		return filepath.Join(home, ".bashrc"), nil
+	}
+	return "", fmt.Errorf("unsupported shell: %s", shell)
+}
+
+// IsShellFunctionInstalled reports whether the wt() wrapper is in the rc
+// file of the user's shell, i.e. whether c in the TUI can cd on exit.
+// IsShellIntegrated only says the setup prompt was answered.
+func IsShellFunctionInstalled() bool {
+	rcFile, err := shellRCFile()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(rcFile)
+	return err == nil && strings.Contains(string(data), shellMarker)
+}
+
+// SetupShellIntegration appends the wt() wrapper to the user's shell rc file.
+func SetupShellIntegration() error {
+	rcFile, err := shellRCFile()
+	if err != nil {
+		return err
 	}
 	fn := `
-# worktree-tui shell integration
+` + shellMarker + `
 wt() {
   worktree-tui "$@"
   if [ -f /tmp/.wt_cd_path ]; then
@@ -0,0 +1,282 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newRepo creates a repo on main with one commit in a temp dir, makes it the
+// working directory for the rest of the test and returns its path. Git runs
+// with a throwaway HOME and identity so the user's config cannot leak in.
+func newRepo(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, k := range []string{"GIT_DIR", "GIT_WORK_TREE"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "Test")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "test@example.com")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "init", "-q", "-b", "main")
+	gitIn(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	chdir(t, dir)
+	return dir
+}
+
+// gitIn runs git in dir and fails the test if it does.
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// chdir changes the working directory until the test ends.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(old) })
+}
+
+func TestParseWorktreeList(t *testing.T) {
+	out, err := os.ReadFile("testdata/worktree-list-z.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wts := parseWorktreeList(string(out))
+	want := []struct {
+		path, branch, name     string
+		main, locked, prunable bool
+		unborn                 bool
+	}{
+		{path: "/src/proj", branch: "main", name: "main", main: true},
+		{path: "/src/proj/.wt/odd\nname", branch: "feat/odd", name: "feat/odd"},
+		{path: "/src/proj/.wt/detached", branch: "(detached)", name: "(detached)"},
+		{path: "/src/proj/.wt/locked", branch: "fix/locked", name: "fix/locked", locked: true},
+		{path: "/gone/stale", branch: "stale", name: "stale", prunable: true},
+		{path: "/src/proj/.wt/unborn", branch: "orphan", name: "orphan", unborn: true},
+	}
+	if len(wts) != len(want) {
+		t.Fatalf("parsed %d worktrees, want %d: %+v", len(wts), len(want), wts)
+	}
+	for i, w := range want {
+		got := wts[i]
+		if got.Path != w.path || got.Branch != w.branch || got.Name != w.name {
+			t.Errorf("worktree %d = %q %q %q, want %q %q %q", i, got.Path, got.Branch, got.Name, w.path, w.branch, w.name)
+		}
+		if got.IsMain != w.main || got.Locked != w.locked || got.Prunable != w.prunable || got.Unborn != w.unborn {
+			t.Errorf("worktree %d flags main=%v locked=%v prunable=%v unborn=%v, want %v %v %v %v",
+				i, got.IsMain, got.Locked, got.Prunable, got.Unborn, w.main, w.locked, w.prunable, w.unborn)
+		}
+	}
+}
+
+func TestParseWorktreeListEmpty(t *testing.T) {
+	if wts := parseWorktreeList(""); len(wts) != 0 {
+		t.Errorf("parseWorktreeList(\"\") = %+v, want none", wts)
+	}
+}
+
+func TestListWorktreesPathWithNewline(t *testing.T) {
+	root := newRepo(t)
+	odd := filepath.Join(root, ".wt", "odd\nname")
+	gitIn(t, root, "worktree", "add", "-q", "-b", "feat/odd", odd)
+
+	wts, err := ListWorktrees(true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wts) != 2 {
+		t.Fatalf("listed %d worktrees, want 2: %+v", len(wts), wts)
+	}
+	if wts[1].Path != odd || wts[1].Branch != "feat/odd" || wts[1].Missing {
+		t.Errorf("linked worktree = %q on %q (missing %v), want %q on feat/odd", wts[1].Path, wts[1].Branch, wts[1].Missing, odd)
+	}
+}
+
+func TestParseWorktreeListBare(t *testing.T) {
+	out, err := os.ReadFile("testdata/worktree-list-bare-z.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wts := parseWorktreeList(string(out))
+	if len(wts) != 3 {
+		t.Fatalf("parsed %d worktrees, want 3: %+v", len(wts), wts)
+	}
+	if wts[0].Branch != "(bare)" {
+		t.Errorf("first entry branch = %q, want (bare)", wts[0].Branch)
+	}
+	for _, wt := range wts {
+		if wt.IsMain {
+			t.Errorf("%s is main; a bare layout has no main worktree", wt.Path)
+		}
+	}
+}
+
+// newBareLayout clones a repo whose HEAD is trunk into proj/.bare and checks
+// out trunk and feat/x as linked worktrees beside it, the "bare repo + all
+// worktrees" layout. It returns proj.
+func newBareLayout(t *testing.T) string {
+	t.Helper()
+	src := newRepo(t)
+	gitIn(t, src, "checkout", "-q", "-b", "trunk")
+	proj := filepath.Join(filepath.Dir(src), "proj")
+	gitIn(t, filepath.Dir(src), "clone", "-q", "--bare", src, filepath.Join(proj, ".bare"))
+	bare := filepath.Join(proj, ".bare")
+	gitIn(t, bare, "worktree", "add", "-q", filepath.Join(proj, "trunk"), "trunk")
+	gitIn(t, bare, "worktree", "add", "-q", "-b", "feat/x", filepath.Join(proj, "feat-x"), "trunk")
+	return proj
+}
+
+func TestBareLayout(t *testing.T) {
+	proj := newBareLayout(t)
+	chdir(t, filepath.Join(proj, "feat-x"))
+
+	if !IsBareLayout() {
+		t.Fatal("IsBareLayout() = false in a bare layout")
+	}
+	if def := GetDefaultBranch(); def != "trunk" {
+		t.Errorf("GetDefaultBranch() = %q, want the bare repo's HEAD, trunk", def)
+	}
+	if name, branch, err := GetRepoInfo(); err != nil || name != "proj" || branch != "feat/x" {
+		t.Errorf("GetRepoInfo() = %q, %q, %v; want proj, feat/x", name, branch, err)
+	}
+	wts, err := ListWorktrees(true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, wt := range wts {
+		paths = append(paths, wt.Path)
+		if wt.IsMain {
+			t.Errorf("%s is main; a bare layout has no main worktree", wt.Path)
+		}
+	}
+	sort.Strings(paths)
+	want := filepath.Join(proj, "feat-x") + " " + filepath.Join(proj, "trunk")
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("listed %s, want %s (the bare repo left out)", got, want)
+	}
+}
+
+func TestBareLayoutFromRepoDir(t *testing.T) {
+	proj := newBareLayout(t)
+	chdir(t, filepath.Join(proj, ".bare"))
+
+	if root, err := GetRepoRoot(); err != nil || root != proj {
+		t.Errorf("GetRepoRoot() = %q, %v; want %q", root, err, proj)
+	}
+	if err := SaveWorktreeMeta("feat/x", "Feature X", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, filepath.Join(proj, "trunk"))
+	if m, ok := GetWorktreeMeta("feat/x"); !ok || m.Name != "Feature X" {
+		t.Errorf("metadata saved from the bare repo reads back from a worktree as %+v, %v", m, ok)
+	}
+}
+
+func TestMetaSharedAcrossWorktrees(t *testing.T) {
+	root := newRepo(t)
+	linked := filepath.Join(root, ".wt", "feat-a")
+	gitIn(t, root, "worktree", "add", "-q", "-b", "feat/a", linked)
+
+	// Saved from the linked worktree, it must land in the main repo's .git.
+	chdir(t, linked)
+	if err := SaveWorktreeMeta("feat/a", "Feature A", "from linked", "abc1234"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git", "worktree-tui", "meta.json")); err != nil {
+		t.Errorf("meta.json not in the shared git dir: %v", err)
+	}
+
+	for _, dir := range []string{root, linked} {
+		chdir(t, dir)
+		m, ok := GetWorktreeMeta("feat/a")
+		if !ok || m.Name != "Feature A" || m.Description != "from linked" || m.CreatedFrom != "abc1234" {
+			t.Errorf("from %s: GetWorktreeMeta = %+v, %v", dir, m, ok)
+		}
+		wts, err := ListWorktrees(true, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wts) != 2 || wts[1].Name != "Feature A" {
+			t.Errorf("from %s: ListWorktrees did not overlay the saved name: %+v", dir, wts)
+		}
+	}
+}
+
+func TestMetaOmitsEmptyFields(t *testing.T) {
+	root := newRepo(t)
+	if err := SaveWorktreeMeta("feat/a", "A", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".git", "worktree-tui", "meta.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"notes"`, `"labels"`} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("meta.json has an empty %s: %s", key, data)
+		}
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	dir := newRepo(t)
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, "commit", "-q", "-am", content)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("base\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "f.txt")
+	gitIn(t, dir, "commit", "-q", "-m", "base")
+	gitIn(t, dir, "branch", "clean")
+	gitIn(t, dir, "checkout", "-q", "-b", "clash")
+	write("clash\n")
+	gitIn(t, dir, "checkout", "-q", "main")
+	write("main\n")
+	// The repo is reached through dir, not the working directory.
+	chdir(t, t.TempDir())
+
+	got, err := MergeConflicts(dir, "clean", "main")
+	if err != nil || len(got) != 0 {
+		t.Errorf("clean branch: conflicts %v, err %v; want none", got, err)
+	}
+	got, err = MergeConflicts(dir, "clash", "main")
+	if err != nil || len(got) != 1 || got[0] != "f.txt" {
+		t.Errorf("clashing branch: conflicts %v, err %v; want [f.txt]", got, err)
+	}
+	if _, err := MergeConflicts(dir, "nope", "main"); err == nil {
+		t.Error("unknown branch: want an error")
+	}
+}
@@ -4,48 +4,90 @@ package types
 type AppState int
 
 const (
-	StateNoGit             AppState = iota // no .git found
-	StateShellSetup                        // first-run shell integration prompt
-	StateList                              // main list + detail view
-	StateNewWorktree                       // modal: create new worktree
-	StateEditWorktree                      // modal: rename branch
-	StateDeleteConfirm                     // modal: confirm delete
-	StateRightPaneFocused                  // Level 2 — commit list navigable in right pane
-	StateCommitDetail                      // Level 3 — commit detail overlay
+	StateNoGit            AppState = iota // no .git found
+	StateShellSetup                       // first-run shell integration prompt
+	StateList                             // main list + detail view
+	StateNewWorktree                      // modal: create new worktree
+	StateEditWorktree                     // modal: edit name, branch, description
+	StateDeleteConfirm                    // modal: confirm delete
+	StateRightPaneFocused                 // Level 2 — commit list navigable in right pane
+	StateCommitDetail                     // Level 3 — commit detail overlay
+	StateMoveWorktree                     // modal: relocate worktree directory
+	StateNotes                            // overlay: edit per-worktree notes
+	StateRepoSwitch                       // overlay: switch to another registered repo
+	StateBranchSwitch                     // overlay: fuzzy switch to / create worktree for any branch
+	StateWorkingDiff                      // overlay: uncommitted changes of a worktree
+	StateActivity                         // overlay: recent commits across all worktrees
+	StateCommitMessage                    // modal: subject + body for a commit from the working diff
+	StateGitLink                          // overlay: a worktree's .git file and admin dir
+	StatePruneMerged                      // modal: confirm deleting merged, clean worktrees, then the results
+	StateLabels                           // modal: edit a worktree's labels
+	StateLabelFilter                      // overlay: pick a label to filter the list by
+	StateHookOutput                       // overlay: output of git hooks run while creating a worktree
+	StateBranchAges                       // overlay: how long each worktree's branch has been in flight
+	StateFileTree                         // overlay: shallow directory tree of a worktree's files
+	StateCDConfirm                        // modal: confirm the directory c will cd to on exit
+	StateCreatePR                         // modal: title, body and draft flag for gh pr create
+	StatePRCheckout                       // overlay: pick an open PR to check out into a new worktree
+	StateMergeConfirm                     // modal: pre-flight check and confirm merging a branch into the default branch
+	StateStash                            // overlay: stash entries with apply, pop, drop and diff
 )
 
 // Worktree holds metadata for a single git worktree.
 type Worktree struct {
-	Name        string   // user-defined display name (from metadata, or branch-derived)
-	Path        string   // absolute filesystem path
-	Branch      string   // git branch name, e.g. "feat/auth-refactor"
-	IsMain      bool     // true for the primary worktree
-	UpdatedAt   string   // human-readable relative time, e.g. "2 hours ago"
-	Description string   // user-defined description (from metadata)
-	CreatedFrom string   // short SHA of HEAD at creation time (from metadata)
-	Ahead       int      // commits ahead of the default branch
-	Behind      int      // commits behind the default branch
-	IsMerged    bool     // whether branch is merged into the default branch
-	Commits     []Commit // last 10 commits
+	Name            string   // user-defined display name (from metadata, or branch-derived)
+	Path            string   // absolute filesystem path
+	Branch          string   // git branch name, e.g. "feat/auth-refactor"
+	IsMain          bool     // true for the primary worktree
+	Unborn          bool     // HEAD names a branch with no commits yet
+	Locked          bool     // git worktree lock is in place
+	Prunable        bool     // git considers the worktree prunable (e.g. directory gone)
+	Missing         bool     // the directory at Path no longer exists
+	Tag             string   // tag name when HEAD is detached exactly at a tag
+	UpdatedAt       string   // human-readable relative time, e.g. "2 hours ago"
+	LastAuthor      string   // author name of the latest commit
+	LastAuthorEmail string   // author email of the latest commit
+	Description     string   // user-defined description (from metadata)
+	Notes           string   // free-form multi-line scratch notes (from metadata)
+	Labels          []string // free-form labels such as "blocked" (from metadata)
+	HasScratchpad   bool     // a non-empty markdown scratchpad exists (notes/<branch>.md)
+	CreatedFrom     string   // short SHA the branch started at (from metadata)
+	Ahead           int      // commits ahead of the default branch
+	Behind          int      // commits behind the default branch
+	IsMerged        bool     // whether branch is merged into the default branch
+	MergeBase       string   // short SHA of the merge-base with the default branch
+	ForkedAge       string   // how long ago MergeBase was committed, e.g. "3 weeks ago"
+	UpstreamGone    bool     // branch tracked a remote branch that has since been deleted
+	SharedWith      []string // paths of other worktrees with the same branch checked out (inconsistent state)
+	NameClashWith   []string // paths of other worktrees listed under the same Name
+	Enriched        bool     // branch status, HEAD, author and commits are loaded (deferred in lazy mode)
+	Commits         []Commit // last 10 commits
 
 	// Detail pane extras.
 	HeadSHA         string // short SHA of current HEAD
 	StatusChanged   int    // count of modified/deleted/renamed files
+	StatusStaged    int    // of StatusChanged, files with changes in the index
+	StatusUnstaged  int    // of StatusChanged, files with changes only in the working tree or both
 	StatusUntracked int    // count of untracked files
+	DirtyAge        string // time since tracked changes were last touched, e.g. "3d"
+	InProgressOp    string // unfinished rebase/merge/cherry-pick/revert/bisect, or ""
 }
 
 // PRInfo holds the result of a gh pr view call.
 type PRInfo struct {
-	State  string // "OPEN", "MERGED", "CLOSED"
-	Number int
-	URL    string
+	State       string // "OPEN", "MERGED", "CLOSED"
+	Number      int
+	URL         string
+	ReviewState string // "APPROVED", "CHANGES_REQUESTED", "REVIEW_REQUIRED", or "" when no review is required
 }
 
 // Commit is a single git commit displayed in the detail pane.
 type Commit struct {
-	Hash    string // short hash, 7 chars
-	Message string // subject line
-	RelTime string // relative time, e.g. "3h ago"
+	Hash    string   // short hash, 7 chars
+	Message string   // subject line
+	RelTime string   // relative time, e.g. "3h ago"
+	Time    int64    // committer time, Unix seconds
+	Refs    []string // full-name decorations, e.g. "HEAD -> refs/heads/x", "tag: refs/tags/v1"
 }
 
 // CommitDetail holds the full data for the commit detail overlay (Level 3).
@@ -54,15 +96,24 @@ type CommitDetail struct {
 	Subject   string
 	Body      string
 	RelTime   string
+	Author    string
+	Committer string
+	Signature string // git's %G? code: "G" good, "B" bad, "N" unsigned, etc.
+	Root      bool   // no parent: the diff adds the whole initial tree
 	Files     []CommitFile
 	Diff      []DiffLine
-	Loaded    bool // false until the async fetch completes
+	Untracked []string // working-diff only: untracked paths, which have no diff
+	Loaded    bool     // false until the async fetch completes
 }
 
 // CommitFile is a single file entry in the "files changed" section.
 type CommitFile struct {
 	Status string // "M", "A", "D", "R"
 	Path   string
+
+	// Working-diff only: where the file's changes currently sit.
+	Staged   bool // some changes are in the index
+	Unstaged bool // some changes are only in the working tree
 }
 
 // DiffLine is one line of the patch, categorised by type.
@@ -2,25 +2,54 @@ package ui
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
+	"github.com/agnishcc/worktree-tui/internal/config"
 	"github.com/agnishcc/worktree-tui/internal/git"
 	"github.com/agnishcc/worktree-tui/internal/types"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// mergeCheck is the outcome of test-merging a branch into the default branch.
+type mergeCheck struct {
+	conflicts []string // conflicted paths; empty when it merges cleanly
+	failed    bool     // merge-tree itself failed, e.g. unrelated histories
+}
+
+// mergePlan is the pre-flight check for merging a branch into the default
+// branch: how many commits it brings, whether the default branch can simply
+// fast-forward, and the paths that would conflict.
+type mergePlan struct {
+	ahead       int
+	fastForward bool
+	conflicts   []string
+	err         error
+}
+
 // prCacheEntry stores the result of a gh pr view call.
 // A nil *PRInfo means the branch has no open PR; a missing key means not yet fetched.
 type prCacheEntry = *types.PRInfo
 
 // Model is the root Bubbletea model.
 type Model struct {
-	state     types.AppState
-	worktrees []types.Worktree
-	repoName  string
-	curBranch string
-	cursor    int // 0 = "+ new worktree", 1..n = worktrees[cursor-1]
-	width     int
-	height    int
+	cfg config.Config
+
+	state        types.AppState
+	worktrees    []types.Worktree // display order (pins first)
+	allWorktrees []types.Worktree // as loaded, in git's order
+	repoName     string
+	curBranch    string
+	cursor       int // 0 = "+ new worktree", 1..n = worktrees[cursor-1]
+	width        int
+	height       int
 
 	// Repo-global header fields (refreshed on every loadWorktrees).
 	remoteURL     string
@@ -28,37 +57,251 @@ type Model struct {
 	fetchedAgo    string
 	defaultBranch string
 
+	// compareBranchOverride, when set (B on a worktree), replaces the
+	// default branch as what every branch's sync status, merge check and
+	// unmerged commits are measured against.
+	compareBranchOverride string
+
 	// PR badge cache: absent key = not fetched; nil value = no PR.
+	// prPending holds the branches whose fetch is in flight.
 	ghAvailable bool
 	prCache     map[string]prCacheEntry
+	prPending   map[string]bool
+
+	// Branch protection on GitHub, fetched with the PR badge: absent key =
+	// not fetched. Protected branches cannot be deleted from the TUI.
+	// protectionPending holds the branches whose lookup is in flight.
+	protectedCache    map[string]bool
+	protectionPending map[string]bool
+
+	// Test-merge results for the selected worktree, keyed by mergeKey.
+	mergeCache map[string]mergeCheck
+
+	// Right-pane commit mode: with unmergedOnly set (toggled with u), only
+	// the commits not on the default branch are listed, cached by
+	// unmergedKey.
+	unmergedOnly  bool
+	unmergedCache map[string][]types.Commit
+
+	// Per-repo UI state (pins), loaded with the worktrees.
+	repoState git.RepoState
+
+	// enclosingRepo is the outer repo this one is nested in, if any; R
+	// switches to it.
+	enclosingRepo string
 
 	// hasCommits is false for a freshly-initialised repo with no commits yet.
 	hasCommits bool
 
+	// modalHelpOpen expands the open modal's inline field help (toggled
+	// with ?). It closes whenever the modal does.
+	modalHelpOpen bool
+
 	// New worktree modal.
-	newTypeIdx      int    // index into branchTypes
-	newTypeListOpen bool   // whether the type-picker overlay is showing
-	newDisplayName  string // shown in the list, allows spaces
-	newBranch       string // git branch (auto-derived from type+name, then editable)
-	newDescription  string // optional free-text description
-	newActiveField  int    // 0=type, 1=name, 2=branch, 3=description
-	newBranchEdited bool   // true once the user manually edits the branch field
+	newTypeIdx      int              // index into branchTypes
+	newTypeListOpen bool             // whether the type-picker overlay is showing
+	newTypeCursor   int              // type-picker row: branchTypes, then cfg.Templates
+	newTemplate     *config.Template // template chosen in the type picker, if any
+	newDisplayName  string           // shown in the list, allows spaces
+	newBranch       string           // git branch (auto-derived from type+name, then editable)
+	newDescription  string           // optional free-text description
+	newActiveField  int              // 0=type, 1=name, 2=branch, 3=description, 4=base
+	newBranchEdited bool             // true once the user manually edits the branch field
+	newWarnings     []string         // CreationWarnings shown for newWarnedFor
+	newWarnedFor    string           // branch already warned about; enter again creates it
+	newBase         string           // start point (tag, SHA or branch); "" = HEAD, or the template's base
+	newBaseFrom     string           // where a seeded newBase came from, shown until it is edited
+	newExisting     string           // local branch picked with ctrl+b; attached instead of created
+	newBranchOpen   bool             // whether the existing-branch picker is showing
+	newBranchPicker picker           // local branches not checked out anywhere
 
 	// Edit modal
-	editName string
+	editDisplayName string
+	editBranch      string
+	editDescription string
+	editTypeIdx     int // index into branchTypes, -1 if the branch has no known type
+	editActiveField int // 0=type, 1=name, 2=branch, 3=description
+
+	// Notes overlay
+	notesDraft string
+
+	// Labels: the editor's comma-separated draft, and the list filter
+	// ("" shows everything) with its picker.
+	labelsDraft  string
+	labelFilter  string
+	labelPicker  picker
+	labelOptions []string // parallel to labelPicker items; "" clears the filter
+
+	// Repo switch overlay: repoPicker items are parallel to repoPaths.
+	repoPicker picker
+	repoPaths  []string
+
+	// Branch switch overlay: branchPicker items are parallel to branchRefs.
+	// branchTarget is set while a create-worktree confirmation is pending,
+	// which also shows branchWarnings.
+	branchPicker   picker
+	branchRefs     []git.BranchRef
+	branchTarget   *branchTarget
+	branchWarnings []string
+
+	// Stash overlay ($). Apply and pop go to the worktree selected when it
+	// was opened (stashPath); d asks again before dropping.
+	stashes        []git.StashEntry
+	stashCursor    int
+	stashPath      string
+	stashName      string
+	stashDropArmed bool
+
+	// PR checkout overlay: prPicker items are parallel to prList, which is
+	// nil until gh has answered. Enter on a PR with prWarnings shows them,
+	// and a second enter checks it out.
+	prPicker    picker
+	prList      []git.PRSummary
+	prWarnings  []string
+	prWarnedFor int // number of the PR prWarnings are for
+
+	// selectPath, when set, moves the cursor to that worktree on the next
+	// reload (e.g. right after creating it).
+	selectPath string
+
+	// Session-scoped undo for metadata edits, most recent last.
+	metaUndo []metaUndoEntry
+
+	// Move modal
+	moveDest string // destination path as typed
+	moveErr  string // inline validation error
 
 	// Commit drill-down (Levels 2 & 3).
-	selectedCommitIndex int              // which commit is highlighted in Level 2
-	commitDetailScroll  int              // vertical scroll offset for Level 3
-	activeCommit        types.CommitDetail // full data shown in the Level 3 overlay
+	selectedCommitIndex int            // which commit is highlighted in Level 2
+	commitDetailScroll  int            // vertical scroll offset for Level 3
+	workingDiffFile     int            // selected file in the working-diff overlay
+	overlayReturn       types.AppState // state esc returns to from the diff overlay
+	rootDiffShown       bool           // a root commit's files and diff are expanded (d)
+
+	// Split commit layout (toggled with s): files on the left, the selected
+	// file's diff on the right, each scrolled on its own.
+	commitSplit     bool
+	splitFile       int // selected file; tab picks it in the stacked layout too
+	splitDiffScroll int // scroll offset of the right-hand diff
+
+	// Commit modal (opened from the working diff).
+	commitSubject     string
+	commitBody        string
+	commitActiveField int  // 0=subject, 1=body
+	commitAll         bool // commit with -a
+	commitErr         string
+
+	// Create PR modal (P). prBranch and prPath are the worktree it was
+	// opened on; prCreating is set while gh runs.
+	prTitle       string
+	prBody        string
+	prDraft       bool
+	prActiveField int // 0=title, 1=body
+	prBranch      string
+	prPath        string
+	prCreating    bool
+	prErr         string
+
+	// Merge confirmation (M): mergeSource's branch goes into the default
+	// branch, checked out in mergeTarget. mergePlan is nil until the
+	// pre-flight check is back.
+	mergeSource types.Worktree
+	mergeTarget types.Worktree
+	mergePlan   *mergePlan
+
+	// Startup prune of merged worktrees (cfg.AutoPruneMerged), also used by
+	// X for finished ones (pruneFinished). pruneResults is nil until the
+	// deletions have run.
+	pruneOffered    bool
+	pruneFinished   bool
+	pruneCandidates []types.Worktree
+	pruneResults    []pruneResult
+
+	// Hook output overlay, shown after a create whose hooks printed
+	// something; hookErr is set when the create (or the hook) failed.
+	hookOut    string
+	hookErr    string
+	hookPath   string // the new worktree
+	hookScroll int
+
+	// .git link overlay for the selected worktree.
+	gitLink git.WorktreeLink
+
+	// File tree overlay for fileTreePath, scrolled by fileTreeScroll.
+	fileTree       []treeEntry
+	fileTreePath   string
+	fileTreeScroll int
+
+	// Branch ages view: how long each worktree's branch has been in
+	// flight, oldest first. nil until the load completes.
+	branchAges       []branchAge
+	branchAgesScroll int
+
+	// Activity view: recent commits across all worktrees, newest first.
+	activity         []activityEntry
+	activityCursor   int
+	activeCommit     types.CommitDetail // full data shown in the Level 3 overlay
+	activeCommitPath string             // worktree the active commit was loaded from
+	diffAlgorithm    string             // current --diff-algorithm, seeded from config
+	diffIgnoreWS     bool               // re-fetch patches with --ignore-all-space
+
+	// Author filter: with mineOnly set, only worktrees whose latest commit
+	// is by userName/userEmail are listed (the main worktree always is).
+	mineOnly  bool
+	userName  string
+	userEmail string
+
+	// Grouping by branch type (toggled with g): headers for feat, fix, …
+	// and "other", with the worktrees of collapsedGroups left out.
+	grouped         bool
+	collapsedGroups map[string]bool
+	groupCursor     string // collapsed group whose header is selected, while cursor is 0
+
+	// listPrefix is a pending "]" or "[" waiting for the key it prefixes.
+	listPrefix string
+
+	// fetchingBranch is the branch a single-branch fetch is running for;
+	// pushingBranch the one p is pushing.
+	fetchingBranch string
+	pushingBranch  string
+
+	// fetchingAll is set while ctrl+f's fetch of every remote runs;
+	// fetchSpin is the header spinner's frame.
+	fetchingAll bool
+	fetchSpin   int
+
+	// quitArmed is set by a first ctrl+c; a second one while it is set
+	// quits. quitSeq tells a stale disarm tick from the current one.
+	quitArmed bool
+	quitSeq   int
 
 	// Transient error
 	errMsg string
+
+	// statusMsg confirms the last action ("fetched feat/x") in the footer
+	// until a statusClearMsg with the matching statusSeq arrives.
+	statusMsg string
+	statusSeq int
+
+	// exitMessage is printed once the TUI has quit (see ExitMessage).
+	exitMessage string
 }
 
 // InitialModel returns the starting model before any data is loaded.
-func InitialModel() Model {
-	return Model{state: types.StateNoGit}
+func InitialModel(cfg config.Config) Model {
+	applyBorder(cfg.BorderStyle)
+	return Model{
+		cfg:           cfg,
+		state:         types.StateNoGit,
+		diffAlgorithm: cfg.DiffAlgorithm,
+	}
+}
+
+// ExitMessage returns text for the caller to print after the program quits,
+// such as a cd command the shell could not be made to run; "" when there is
+// none.
+func (m Model) ExitMessage() string {
+	return m.exitMessage
 }
 
 // Init sends the initial git-detection command.
@@ -68,7 +311,12 @@ func (m Model) Init() tea.Cmd {
 
 // ── Async messages ────────────────────────────────────────────────────────────
 
-type gitCheckMsg struct{ isGit bool }
+// gitCheckMsg reports whether the working directory is in a repo and, as
+// that only changes with the directory, the repo it is nested in.
+type gitCheckMsg struct {
+	isGit         bool
+	enclosingRepo string
+}
 
 type worktreesLoadedMsg struct {
 	worktrees     []types.Worktree
@@ -80,19 +328,129 @@ type worktreesLoadedMsg struct {
 	defaultBranch string
 	ghAvailable   bool
 	hasCommits    bool
+	repoState     git.RepoState
+	userName      string
+	userEmail     string
 	err           error
 }
 
+// pruneCandidatesMsg carries the worktrees a prune prompt should offer and
+// the protection looked up for their branches along the way. finished marks
+// the X prompt rather than the startup offer.
+type pruneCandidatesMsg struct {
+	worktrees []types.Worktree
+	protected map[string]bool
+	finished  bool
+}
+
+// pruneResult is the outcome of deleting one worktree in a prune.
+type pruneResult struct {
+	name string
+	err  error
+}
+
+type prunedMsg struct{ results []pruneResult }
+
 type gitInitMsg struct{ err error }
-type worktreeCreatedMsg struct{ err error }
-type worktreeDeletedMsg struct{ err error }
-type worktreeRenamedMsg struct{ err error }
+type repoSwitchedMsg struct{ err error }
+type repoStateSavedMsg struct{ err error }
+type worktreeCreatedMsg struct {
+	path    string
+	hookOut string // what git's hooks printed during the checkout
+	err     error
+}
+type worktreeDeletedMsg struct {
+	path   string
+	pruned bool // only git's record went; the directory was already missing
+	err    error
+}
+type worktreeEditedMsg struct {
+	undo *metaUndoEntry // set when name/description changed
+	err  error
+}
+
+type branchFetchedMsg struct{ err error }
+type allFetchedMsg struct{ err error }
+type branchPushedMsg struct{ err error }
+type stashesLoadedMsg struct {
+	entries []git.StashEntry
+	err     error
+}
+type stashDoneMsg struct {
+	verb string // "applied", "popped" or "dropped"
+	ref  string
+	err  error
+}
+type mergePlanMsg struct{ plan mergePlan }
+type branchMergedMsg struct{ err error }
+type prListMsg struct {
+	prs []git.PRSummary
+	err error
+}
+type prCreatedMsg struct {
+	branch string
+	info   *types.PRInfo
+	err    error
+}
+type fileStagedMsg struct{ err error }
+type initialCommitMsg struct{ err error }
+type committedMsg struct{ err error }
+type metaUndoneMsg struct{ err error }
+type worktreeMovedMsg struct {
+	to  string
+	err error
+}
+
+// quitDisarmMsg ends the window for a second ctrl+c started by press seq.
+type quitDisarmMsg struct{ seq int }
+
+// fetchSpinMsg advances the header spinner while fetchingAll is set.
+type fetchSpinMsg struct{}
+
+// statusClearMsg expires the status message set with sequence number seq.
+type statusClearMsg struct{ seq int }
+
+// execDoneMsg is sent when an editor or shell launched via tea.ExecProcess exits.
+type execDoneMsg struct{ err error }
 
 type prFetchedMsg struct {
 	branch string
 	info   *types.PRInfo // nil = no PR
 }
 
+// copiedMsg reports a clipboard copy; what names the copied thing for the
+// status line, e.g. "commit link".
+type copiedMsg struct {
+	what string
+	err  error
+}
+
+type protectionMsg struct {
+	branch    string
+	protected bool
+}
+
+type worktreeEnrichedMsg struct{ wt types.Worktree }
+
+type fileTreeMsg struct {
+	path    string
+	entries []treeEntry
+	err     error
+}
+
+type branchAgesMsg struct{ ages []branchAge }
+
+type unmergedCommitsMsg struct {
+	key     string
+	commits []types.Commit
+	err     error
+}
+
+type mergeCheckedMsg struct {
+	key   string
+	check mergeCheck
+}
+
 type commitDetailLoadedMsg struct {
 	detail *types.CommitDetail
 	err    error
@@ -101,13 +459,28 @@ type commitDetailLoadedMsg struct {
 // ── Commands ──────────────────────────────────────────────────────────────────
 
 func checkGitRepo() tea.Msg {
-	return gitCheckMsg{isGit: git.IsGitRepo()}
+	if !git.IsGitRepo() {
+		return gitCheckMsg{}
+	}
+	// Every launch registers the repo for the switch overlay.
+	if root, err := git.GetMainWorktreePath(); err == nil {
+		_ = config.RegisterRepo(root)
+	}
+	return gitCheckMsg{isGit: true, enclosingRepo: git.GetEnclosingRepo()}
+}
+
+// switchRepo changes the process working directory, which every git call
+// runs relative to.
+func switchRepo(path string) tea.Cmd {
+	return func() tea.Msg { return repoSwitchedMsg{err: os.Chdir(path)} }
 }
 
-func loadWorktrees() tea.Cmd {
+func (m Model) loadWorktrees() tea.Cmd {
+	lazy := m.cfg.LazyEnrichment
+	compare := m.compareBranchOverride
 	return func() tea.Msg {
 		root, _ := git.GetRepoRoot()
-		wts, err := git.ListWorktrees()
+		wts, err := git.ListWorktrees(lazy, compare)
 		if err != nil {
 			return worktreesLoadedMsg{err: err}
 		}
@@ -115,6 +488,8 @@ func loadWorktrees() tea.Cmd {
 		remoteURL, _ := git.GetRemoteURL()
 		stashCount, _ := git.GetStashCount()
 		fetchedAgo, _ := git.GetFetchedAgo()
+		state, _ := git.LoadRepoState()
+		userName, userEmail := git.GetUserIdentity()
 		return worktreesLoadedMsg{
 			worktrees:     wts,
 			repoName:      name,
@@ -125,24 +500,405 @@ func loadWorktrees() tea.Cmd {
 			defaultBranch: git.GetDefaultBranch(),
 			ghAvailable:   git.IsGHAvailable(),
 			hasCommits:    git.HasCommits(root),
+			repoState:     state,
+			userName:      userName,
+			userEmail:     userEmail,
+		}
+	}
+}
+
+func loadCommitDetail(worktreePath, sha string, opts git.DiffOptions) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := git.GetCommitDetail(worktreePath, sha, opts)
+		return commitDetailLoadedMsg{detail: detail, err: err}
+	}
+}
+
+func loadStashDetail(worktreePath, ref string, opts git.DiffOptions) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := git.GetStashDetail(worktreePath, ref, opts)
+		return commitDetailLoadedMsg{detail: detail, err: err}
+	}
+}
+
+func loadStashes() tea.Msg {
+	entries, err := git.ListStashes()
+	return stashesLoadedMsg{entries: entries, err: err}
+}
+
+// applyStash applies, pops or drops (verb) the stash entry ref; path is
+// the worktree to apply it to.
+func applyStash(path, ref, verb string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		switch verb {
+		case "dropped":
+			err = git.DropStash(ref)
+		default:
+			err = git.ApplyStash(path, ref, verb == "popped")
 		}
+		return stashDoneMsg{verb: verb, ref: ref, err: err}
 	}
 }
 
-func loadCommitDetail(worktreePath, sha string) tea.Cmd {
+func loadWorkingDiff(worktreePath string, opts git.DiffOptions) tea.Cmd {
 	return func() tea.Msg {
-		detail, err := git.GetCommitDetail(worktreePath, sha)
+		detail, err := git.GetWorkingDiff(worktreePath, opts)
+		if err != nil {
+			return commitDetailLoadedMsg{err: err}
+		}
+		files, err := git.GetWorktreeFiles(worktreePath)
+		for _, f := range files {
+			if f.Untracked() {
+				detail.Untracked = append(detail.Untracked, f.Path)
+			}
+		}
 		return commitDetailLoadedMsg{detail: detail, err: err}
 	}
 }
 
+// stageAll stages (or, with unstage set, unstages) everything in a worktree.
+// It reuses fileStagedMsg, whose handler refreshes the list.
+func stageAll(worktreePath string, unstage bool) tea.Cmd {
+	return func() tea.Msg {
+		if unstage {
+			return fileStagedMsg{err: git.UnstageAll(worktreePath)}
+		}
+		return fileStagedMsg{err: git.StageAll(worktreePath)}
+	}
+}
+
+// stageFile stages (or, with unstage set, unstages) one file.
+func stageFile(worktreePath, file string, unstage bool) tea.Cmd {
+	return func() tea.Msg {
+		if unstage {
+			return fileStagedMsg{err: git.UnstageFile(worktreePath, file)}
+		}
+		return fileStagedMsg{err: git.StageFile(worktreePath, file)}
+	}
+}
+
+func commitChanges(worktreePath, message string, all bool) tea.Cmd {
+	return func() tea.Msg {
+		if all {
+			return committedMsg{err: git.CommitAll(worktreePath, message)}
+		}
+		return committedMsg{err: git.Commit(worktreePath, message)}
+	}
+}
+
+// prSlots caps concurrent gh calls for PR badges, so a prefetch across
+// many worktrees doesn't fire them all at once.
+var prSlots = make(chan struct{}, 4)
+
 func fetchPR(branch string) tea.Cmd {
 	return func() tea.Msg {
+		prSlots <- struct{}{}
+		defer func() { <-prSlots }()
 		info, _ := git.GetPRInfo(branch)
 		return prFetchedMsg{branch: branch, info: info}
 	}
 }
 
+func createPR(path, branch, title, body string, draft bool) tea.Cmd {
+	return func() tea.Msg {
+		info, err := git.CreatePR(path, branch, title, body, draft)
+		return prCreatedMsg{branch: branch, info: info, err: err}
+	}
+}
+
+func fetchProtection(branch string) tea.Cmd {
+	return func() tea.Msg {
+		return protectionMsg{branch: branch, protected: git.IsBranchProtected(branch)}
+	}
+}
+
+// copyCommitLink copies the web link for a commit, or just its full SHA when
+// the remote's host has no known web URL.
+func copyCommitLink(worktreePath, sha string) tea.Cmd {
+	return func() tea.Msg {
+		link, err := git.CommitWebURL(worktreePath, sha)
+		if err != nil {
+			full, rerr := git.ResolveCommit(worktreePath, sha)
+			if rerr != nil {
+				return copiedMsg{err: err}
+			}
+			return copiedMsg{what: "commit SHA (no web URL for this remote)", err: copyToClipboard(full)}
+		}
+		return copiedMsg{what: "commit link", err: copyToClipboard(link)}
+	}
+}
+
+// copyFileList copies the paths of files, one per line.
+func copyFileList(files []types.CommitFile) tea.Cmd {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	what := fmt.Sprintf("%d file paths", len(paths))
+	if len(paths) == 1 {
+		what = "1 file path"
+	}
+	return func() tea.Msg {
+		return copiedMsg{what: what, err: copyToClipboard(strings.Join(paths, "\n") + "\n")}
+	}
+}
+
+// enrichWorktree loads the details lazy mode left out for wt, comparing
+// against compare ("" for the default branch).
+func enrichWorktree(wt types.Worktree, compare string) tea.Cmd {
+	return func() tea.Msg {
+		git.EnrichWorktree(&wt, compare)
+		return worktreeEnrichedMsg{wt: wt}
+	}
+}
+
+// createInitialCommit records an empty first commit in a repo that has none,
+// so worktrees can branch off it.
+func createInitialCommit(sign bool) tea.Cmd {
+	return func() tea.Msg {
+		root, err := git.GetRepoRoot()
+		if err != nil {
+			return initialCommitMsg{err: err}
+		}
+		return initialCommitMsg{err: git.CreateEmptyCommit(root, "Initial commit", sign)}
+	}
+}
+
+// checkMerge test-merges branch, checked out at path, into def off the UI
+// thread.
+func checkMerge(key, path, branch, def string) tea.Cmd {
+	return func() tea.Msg {
+		conflicts, err := git.MergeConflicts(path, branch, def)
+		return mergeCheckedMsg{key: key, check: mergeCheck{conflicts: conflicts, failed: err != nil}}
+	}
+}
+
+// planMerge runs the pre-flight check for merging branch into def, which is
+// checked out at path.
+func planMerge(path, branch, def string) tea.Cmd {
+	return func() tea.Msg {
+		ahead, behind, _, _ := git.GetBranchStatus(branch, def)
+		conflicts, err := git.MergeConflicts(path, branch, def)
+		return mergePlanMsg{plan: mergePlan{ahead: ahead, fastForward: behind == 0, conflicts: conflicts, err: err}}
+	}
+}
+
+func mergeBranch(path, branch string) tea.Cmd {
+	return func() tea.Msg {
+		return branchMergedMsg{err: git.MergeBranch(path, branch)}
+	}
+}
+
+// loadUnmergedCommits lists the commits of the worktree at path that def
+// does not have.
+func loadUnmergedCommits(key, path, def string) tea.Cmd {
+	return func() tea.Msg {
+		commits, err := git.GetCommits(path, def)
+		return unmergedCommitsMsg{key: key, commits: commits, err: err}
+	}
+}
+
+// loadFileTree lists the worktree at path as a fileTreeDepth-level tree.
+func loadFileTree(path string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := git.ListFiles(path)
+		return fileTreeMsg{path: path, entries: buildFileTree(files, fileTreeDepth), err: err}
+	}
+}
+
+// loadBranchAges finds when work began on each worktree's branch, relative
+// to def. Worktrees on def itself, detached or unborn are left out.
+func loadBranchAges(wts []types.Worktree, def string) tea.Cmd {
+	return func() tea.Msg {
+		ages := []branchAge{}
+		for _, wt := range wts {
+			if wt.Branch == def || wt.Unborn || strings.HasPrefix(wt.Branch, "(") {
+				continue
+			}
+			start, unique, err := git.BranchStart(wt.Branch, def)
+			if err != nil {
+				continue
+			}
+			ages = append(ages, branchAge{name: wt.Name, start: start, age: git.FormatAge(time.Since(start)), unique: unique})
+		}
+		sort.SliceStable(ages, func(i, j int) bool { return ages[i].start.Before(ages[j].start) })
+		return branchAgesMsg{ages: ages}
+	}
+}
+
+// openInEditor suspends the TUI and opens dir in $VISUAL / $EDITOR (vi if unset).
+func openInEditor(dir string) tea.Cmd {
+	return execCommand(editorCommand(dir, dir))
+}
+
+// editScratchpad opens branch's markdown scratchpad in the editor from the
+// worktree at dir, creating its directory first so the editor can save it.
+func editScratchpad(dir, branch string) tea.Cmd {
+	path, err := git.ScratchpadPath(branch)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return func() tea.Msg { return execDoneMsg{err: err} }
+	}
+	return execCommand(editorCommand(path, dir))
+}
+
+// execCommand suspends the TUI to run c, or reports err if building c failed.
+func execCommand(c *exec.Cmd, err error) tea.Cmd {
+	if err != nil {
+		return func() tea.Msg { return execDoneMsg{err: err} }
+	}
+	return tea.ExecProcess(c, execResult)
+}
+
+// editorCommand runs $VISUAL, $EDITOR or vi on target from dir.
+func editorCommand(target, dir string) (*exec.Cmd, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	return commandOn(editor, target, dir)
+}
+
+// commandOn runs the command line prog, split on spaces (so e.g.
+// EDITOR="code -w" works), with target appended, from dir. A prog of only
+// whitespace is an error rather than a command.
+func commandOn(prog, target, dir string) (*exec.Cmd, error) {
+	args := strings.Fields(prog)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command to open %s with — check $VISUAL, $EDITOR and openCommands", filepath.Base(target))
+	}
+	c := exec.Command(args[0], append(args[1:], target)...)
+	c.Dir = dir
+	c.Env = git.EnvFor(dir)
+	return c, nil
+}
+
+// openFile opens target with cfg.OpenCommands' entry for its extension,
+// or the editor.
+func (m Model) openFile(target, dir string) tea.Cmd {
+	if prog := m.cfg.OpenCommands[strings.ToLower(filepath.Ext(target))]; strings.TrimSpace(prog) != "" {
+		return execCommand(commandOn(prog, target, dir))
+	}
+	return execCommand(editorCommand(target, dir))
+}
+
+// fileAtRevMsg carries the temp copy of a file as of a commit, ready to open.
+type fileAtRevMsg struct {
+	path string
+	dir  string
+	err  error
+}
+
+// snapshots is the temp dir o's copies of files at a revision go in, made on
+// first use and removed by RemoveSnapshots when the program exits. The
+// copies outlive the editor call because GUI openers return before reading
+// them.
+var snapshots struct {
+	once sync.Once
+	dir  string
+	err  error
+}
+
+func snapshotDir() (string, error) {
+	snapshots.once.Do(func() {
+		snapshots.dir, snapshots.err = os.MkdirTemp("", "worktree-tui-")
+	})
+	return snapshots.dir, snapshots.err
+}
+
+// RemoveSnapshots deletes the temp copies of files opened at a revision
+// this session. Call it once the program has exited.
+func RemoveSnapshots() {
+	if snapshots.dir != "" {
+		_ = os.RemoveAll(snapshots.dir)
+	}
+}
+
+// snapshotFileAtRev writes file as of rev to a read-only temp file, keeping
+// its base name so editors pick the right syntax. A file the commit deleted
+// is taken from rev's parent instead.
+func snapshotFileAtRev(dir, rev string, f types.CommitFile) tea.Cmd {
+	return func() tea.Msg {
+		if f.Status == "D" {
+			rev += "^"
+		}
+		content, err := git.ShowFileAtRev(dir, rev, f.Path)
+		if err != nil {
+			return fileAtRevMsg{err: err}
+		}
+		tmpDir, err := snapshotDir()
+		if err != nil {
+			return fileAtRevMsg{err: err}
+		}
+		tmp, err := os.CreateTemp(tmpDir, strings.TrimSuffix(rev, "^")+"-*-"+filepath.Base(f.Path))
+		if err != nil {
+			return fileAtRevMsg{err: err}
+		}
+		_, err = tmp.WriteString(content)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), 0o444)
+		}
+		return fileAtRevMsg{path: tmp.Name(), dir: dir, err: err}
+	}
+}
+
+// execResult turns the outcome of an ExecProcess into an execDoneMsg. A
+// program that ran and exited non-zero (an aborted edit, a shell whose last
+// command failed) is not worktree-tui's failure, so only failures to start
+// it are reported.
+func execResult(err error) tea.Msg {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return execDoneMsg{}
+	}
+	return execDoneMsg{err: err}
+}
+
+// openShell suspends the TUI and starts an interactive $SHELL in dir.
+// Exiting the shell returns to the list.
+func openShell(dir string) tea.Cmd {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	c := exec.Command(shell)
+	c.Dir = dir
+	c.Env = git.EnvFor(dir)
+	return tea.ExecProcess(c, execResult)
+}
+
+// openTerminal opens a shell in dir: with tmux integration on and $TMUX
+// set, as a new tmux window named name (the TUI keeps running); otherwise
+// via openShell.
+func (m Model) openTerminal(dir, name string) tea.Cmd {
+	if !m.cfg.TmuxIntegration || os.Getenv("TMUX") == "" {
+		return openShell(dir)
+	}
+	return func() tea.Msg {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			return execDoneMsg{err: errors.New("tmuxIntegration is on but tmux is not on PATH")}
+		}
+		out, err := exec.Command("tmux", "new-window", "-c", dir, "-n", name).CombinedOutput()
+		if err != nil && len(out) > 0 {
+			err = fmt.Errorf("tmux: %s", strings.TrimSpace(string(out)))
+		}
+		return execDoneMsg{err: err}
+	}
+}
+
+func saveRepoState(st git.RepoState) tea.Cmd {
+	return func() tea.Msg { return repoStateSavedMsg{err: git.SaveRepoState(st)} }
+}
+
 func initGitRepo() tea.Msg {
 	return gitInitMsg{err: git.InitRepo()}
 }
@@ -151,34 +907,412 @@ func initGitRepo() tea.Msg {
 func (m *Model) resetNewModal() {
 	m.newTypeIdx = 0
 	m.newTypeListOpen = false
+	m.newTypeCursor = 0
+	m.newTemplate = nil
 	m.newDisplayName = ""
 	m.newBranch = ""
 	m.newDescription = ""
 	m.newActiveField = 0
 	m.newBranchEdited = false
+	m.newWarnings = nil
+	m.newWarnedFor = ""
+	m.newBase = ""
+	m.newBaseFrom = ""
+	m.newExisting = ""
+	m.newBranchOpen = false
 }
 
-func createWorktree(displayName, branch, path, description string) tea.Cmd {
+// createWorktree runs CreateWorktree for the new-worktree form.
+func createWorktree(displayName, branch, path, description, base string, tmpl *config.Template, sign bool) tea.Cmd {
 	return func() tea.Msg {
-		root, _ := git.GetRepoRoot()
-		if !git.HasCommits(root) {
-			return worktreeCreatedMsg{err: errors.New("repo has no commits yet — make an initial commit on main before creating worktrees")}
+		created, hookOut, err := CreateWorktree(displayName, branch, path, description, base, tmpl, sign)
+		if !created {
+			return worktreeCreatedMsg{err: err}
+		}
+		return worktreeCreatedMsg{path: path, hookOut: hookOut, err: err}
+	}
+}
+
+// attachWorktree runs AttachWorktree for the new-worktree form.
+func attachWorktree(displayName, branch, path, description string, tmpl *config.Template) tea.Cmd {
+	return func() tea.Msg {
+		created, hookOut, err := AttachWorktree(displayName, branch, path, description, tmpl)
+		if !created {
+			return worktreeCreatedMsg{err: err}
+		}
+		return worktreeCreatedMsg{path: path, hookOut: hookOut, err: err}
+	}
+}
+
+// templateBase returns base, or when it is empty the template's start point
+// with "latest-tag" resolved.
+func templateBase(base string, tmpl *config.Template) (string, error) {
+	if base != "" || tmpl == nil {
+		return base, nil
+	}
+	if tmpl.Base != config.BaseLatestTag {
+		return tmpl.Base, nil
+	}
+	tag, err := git.LatestTag()
+	if err != nil {
+		return "", fmt.Errorf("template %s: no tag to branch from: %w", tmpl.Name, err)
+	}
+	return tag, nil
+}
+
+// copyAddCommand copies the git worktree add command CreateWorktree would
+// run for these inputs, or AttachWorktree's when existing is set.
+func copyAddCommand(branch, path, base string, existing bool, tmpl *config.Template) tea.Cmd {
+	return func() tea.Msg {
+		base, err := templateBase(base, tmpl)
+		if err != nil {
+			return copiedMsg{err: err}
+		}
+		args := git.AddWorktreeArgs(branch, path, base)
+		if existing {
+			args = []string{"worktree", "add", path, branch}
+		}
+		words := []string{"git"}
+		for _, a := range args {
+			words = append(words, shellQuote(a))
+		}
+		return copiedMsg{what: "git worktree add command", err: copyToClipboard(strings.Join(words, " "))}
+	}
+}
+
+// shellQuote single-quotes s for sh when it has anything but safe characters.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_./:@%+=,", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CreationWarnings returns what to confirm before adding a worktree when
+// count already exist: going past cfg.MaxWorktrees and, for a branch that
+// will be created, a tag of the same name, which makes refs ambiguous. Pass
+// "" as branch when checking out one that already exists. The form, the
+// branch switch, PR checkout and the new subcommand all ask it.
+func CreationWarnings(cfg config.Config, branch string, count int) []string {
+	var warnings []string
+	if limit := cfg.MaxWorktrees; limit > 0 && count >= limit {
+		warnings = append(warnings, fmt.Sprintf("already %d worktrees — maxWorktrees is %d", count, limit))
+	}
+	if branch != "" && git.TagExists(branch) {
+		warnings = append(warnings, "a tag named "+branch+" exists — refs will be ambiguous")
+	}
+	return warnings
+}
+
+// CreateWorktree creates a worktree for a new branch at path and saves its
+// metadata. The branch starts at base, else the template's base, else HEAD.
+// With sign set, it starts with a signed empty commit for provenance. When
+// tmpl is set, its commands run in the new directory afterwards; a failing
+// command stops the rest. created reports whether the worktree exists, which
+// it still does when only a hook, the signed commit or a template command
+// failed. hookOut is what git's hooks printed while checking it out; after a
+// failed post-checkout hook nothing else is run.
+func CreateWorktree(displayName, branch, path, description, base string, tmpl *config.Template, sign bool) (created bool, hookOut string, err error) {
+	root, _ := git.GetRepoRoot()
+	if !git.HasCommits(root) {
+		return false, "", errors.New("repo has no commits yet — make an initial commit on main before creating worktrees")
+	}
+	base, err = templateBase(base, tmpl)
+	if err != nil {
+		return false, "", err
+	}
+	hookOut, err = git.AddWorktree(branch, path, base)
+	var hookErr *git.HookError
+	if err != nil && !errors.As(err, &hookErr) {
+		return false, "", err
+	}
+	// The new branch's HEAD is base resolved, read before any signed commit.
+	from, _ := git.GetHeadSHA(path)
+	_ = git.SaveWorktreeMeta(branch, displayName, description, from)
+	if err != nil {
+		return true, hookOut, err
+	}
+	// A signing problem is reported but does not stop template setup.
+	var signErr error
+	if sign {
+		if !git.SigningConfigured() {
+			signErr = errors.New("signCommits is on but user.signingkey is not set — skipped the signed start commit")
+		} else if err := git.CreateEmptyCommit(path, "Start "+branch, true); err != nil {
+			signErr = fmt.Errorf("signed start commit: %w", err)
+		}
+	}
+	if err := runTemplate(tmpl, root, path, displayName, branch); err != nil {
+		return true, hookOut, err
+	}
+	return true, hookOut, signErr
+}
+
+// AttachWorktree checks out the existing local branch into a new worktree
+// at path and saves its metadata, then runs tmpl's commands as
+// CreateWorktree does. No branch is created and no commit is made.
+func AttachWorktree(displayName, branch, path, description string, tmpl *config.Template) (created bool, hookOut string, err error) {
+	root, _ := git.GetRepoRoot()
+	hookOut, err = git.AddWorktreeForBranch(branch, path)
+	var hookErr *git.HookError
+	if err != nil && !errors.As(err, &hookErr) {
+		return false, "", err
+	}
+	// The branch already existed, so where it started is unknown here. Its
+	// entry may outlive a pruned worktree, so keep its notes and labels.
+	meta, _ := git.GetWorktreeMeta(branch)
+	meta.Name, meta.Description, meta.CreatedFrom = displayName, description, ""
+	_ = git.SetWorktreeMeta(branch, meta)
+	if err != nil {
+		return true, hookOut, err
+	}
+	return true, hookOut, runTemplate(tmpl, root, path, displayName, branch)
+}
+
+// runTemplate runs tmpl's commands in the worktree at path, stopping at the
+// first that fails. A nil tmpl runs nothing.
+func runTemplate(tmpl *config.Template, root, path, displayName, branch string) error {
+	if tmpl == nil {
+		return nil
+	}
+	for _, c := range tmpl.Commands {
+		c = tmpl.Expand(c, root, displayName, branch)
+		if out, err := git.RunShell(path, c); err != nil {
+			if out != "" {
+				err = fmt.Errorf("%w: %s", err, lastLine(out))
+			}
+			return fmt.Errorf("template %s: %q failed: %w", tmpl.Name, c, err)
 		}
-		if err := git.AddWorktree(branch, path); err != nil {
+	}
+	return nil
+}
+
+func fetchBranch(path, branch string) tea.Cmd {
+	return func() tea.Msg {
+		return branchFetchedMsg{err: git.FetchBranch(path, branch)}
+	}
+}
+
+func pushBranch(path, branch string) tea.Cmd {
+	return func() tea.Msg {
+		return branchPushedMsg{err: git.PushBranch(path, branch)}
+	}
+}
+
+func fetchAll() tea.Cmd {
+	return func() tea.Msg {
+		return allFetchedMsg{err: git.FetchAll()}
+	}
+}
+
+// fetchSpinInterval is how often the header spinner advances.
+const fetchSpinInterval = 100 * time.Millisecond
+
+func fetchSpinTick() tea.Cmd {
+	return tea.Tick(fetchSpinInterval, func(time.Time) tea.Msg { return fetchSpinMsg{} })
+}
+
+// lastLine returns the final line of s.
+func lastLine(s string) string {
+	return s[strings.LastIndex(s, "\n")+1:]
+}
+
+// createWorktreeForTarget checks out an existing local branch, or a new
+// branch tracking a remote one, into a worktree at t.path.
+func createWorktreeForTarget(t branchTarget) tea.Cmd {
+	path := t.path
+	return func() tea.Msg {
+		var hookOut string
+		var err error
+		if t.ref.Remote != "" {
+			hookOut, err = git.AddTrackingWorktree(t.ref.Ref(), t.ref.Name, path)
+		} else {
+			hookOut, err = git.AddWorktreeForBranch(t.ref.Name, path)
+		}
+		var hookErr *git.HookError
+		if err != nil && !errors.As(err, &hookErr) {
+			return worktreeCreatedMsg{err: err}
+		}
+		return worktreeCreatedMsg{path: path, hookOut: hookOut, err: err}
+	}
+}
+
+func listOpenPRs() tea.Msg {
+	prs, err := git.ListOpenPRs()
+	return prListMsg{prs: prs, err: err}
+}
+
+// checkoutPR creates a worktree at path for pr's branch.
+func checkoutPR(pr git.PRSummary, path string) tea.Cmd {
+	return func() tea.Msg {
+		hookOut, err := git.AddPRWorktree(pr, path)
+		var hookErr *git.HookError
+		if err != nil && !errors.As(err, &hookErr) {
 			return worktreeCreatedMsg{err: err}
 		}
-		_ = git.SaveWorktreeMeta(branch, displayName, description)
-		return worktreeCreatedMsg{}
+		return worktreeCreatedMsg{path: path, hookOut: hookOut, err: err}
 	}
 }
 
+// WorktreePath returns the default location for a branch's worktree:
+// <root>/.wt/<branch with slashes replaced by dashes>.
+func WorktreePath(root, branch string) string {
+	return filepath.Join(root, ".wt", strings.ReplaceAll(branch, "/", "-"))
+}
+
 func deleteWorktree(branch, path string) tea.Cmd {
 	return func() tea.Msg {
 		_ = git.DeleteWorktreeMeta(branch)
-		return worktreeDeletedMsg{err: git.RemoveWorktree(path)}
+		return worktreeDeletedMsg{path: path, err: git.RemoveWorktree(path)}
+	}
+}
+
+func pruneWorktree(branch, path string) tea.Cmd {
+	return func() tea.Msg {
+		err := git.PruneWorktree(path)
+		if err == nil {
+			_ = git.DeleteWorktreeMeta(branch)
+		}
+		return worktreeDeletedMsg{path: path, pruned: true, err: err}
+	}
+}
+
+// findPruneCandidates narrows merged, clean worktrees down to those with
+// nothing unpushed either, then drops protected branches.
+func findPruneCandidates(wts []types.Worktree, known map[string]bool, checkGH bool) tea.Cmd {
+	return func() tea.Msg {
+		var out []types.Worktree
+		for _, wt := range wts {
+			if git.UnpushedCount(wt.Branch) == 0 {
+				out = append(out, wt)
+			}
+		}
+		out, protected := dropProtected(out, known, checkGH)
+		return pruneCandidatesMsg{worktrees: out, protected: protected}
+	}
+}
+
+// findFinished drops protected branches from the finished worktrees X
+// offers to delete.
+func findFinished(wts []types.Worktree, known map[string]bool, checkGH bool) tea.Cmd {
+	return func() tea.Msg {
+		out, protected := dropProtected(wts, known, checkGH)
+		return pruneCandidatesMsg{worktrees: out, protected: protected, finished: true}
+	}
+}
+
+// dropProtected returns wts without the worktrees whose branch is
+// protected, asking GitHub about each branch known does not already answer
+// for, and what it learned. Without gh nothing is asked.
+func dropProtected(wts []types.Worktree, known map[string]bool, checkGH bool) ([]types.Worktree, map[string]bool) {
+	learned := make(map[string]bool)
+	var out []types.Worktree
+	for _, wt := range wts {
+		protected, ok := known[wt.Branch]
+		if !ok && checkGH {
+			protected = git.IsBranchProtected(wt.Branch)
+			learned[wt.Branch] = protected
+		}
+		if !protected {
+			out = append(out, wt)
+		}
+	}
+	return out, learned
+}
+
+// pruneWorktrees deletes each worktree in turn, carrying on past failures.
+func pruneWorktrees(wts []types.Worktree) tea.Cmd {
+	return func() tea.Msg {
+		var results []pruneResult
+		for _, wt := range wts {
+			err := git.RemoveWorktree(wt.Path)
+			if err == nil {
+				_ = git.DeleteWorktreeMeta(wt.Branch)
+			}
+			results = append(results, pruneResult{name: wt.Name, err: err})
+		}
+		return prunedMsg{results: results}
+	}
+}
+
+// maxMetaUndo caps how many metadata edits can be undone in a session.
+const maxMetaUndo = 10
+
+// metaUndoEntry records a branch's metadata as it was before an edit.
+type metaUndoEntry struct {
+	branch  string
+	prev    git.WorktreeMeta
+	hadPrev bool // false if the branch had no metadata entry before the edit
+}
+
+// saveWorktreeEdit renames the branch (migrating its metadata) if it changed,
+// then stores the new name and description when they differ. A worktree at
+// the old branch's default location moves to the new one's.
+func saveWorktreeEdit(oldBranch, newBranch, path, name, description string, metaChanged bool) tea.Cmd {
+	return func() tea.Msg {
+		if newBranch != oldBranch {
+			if err := git.RenameBranch(oldBranch, newBranch); err != nil {
+				return worktreeEditedMsg{err: err}
+			}
+			_ = git.RenameWorktreeMeta(oldBranch, newBranch)
+			root, _ := git.GetRepoRoot()
+			if path == WorktreePath(root, oldBranch) {
+				if err := git.MoveWorktree(path, WorktreePath(root, newBranch)); err != nil {
+					return worktreeEditedMsg{err: fmt.Errorf("branch renamed, but moving the worktree failed: %w", err)}
+				}
+			}
+		}
+		if !metaChanged {
+			return worktreeEditedMsg{}
+		}
+		prev, hadPrev := git.GetWorktreeMeta(newBranch)
+		next := prev
+		next.Name = name
+		next.Description = description
+		if err := git.SetWorktreeMeta(newBranch, next); err != nil {
+			return worktreeEditedMsg{err: err}
+		}
+		return worktreeEditedMsg{undo: &metaUndoEntry{branch: newBranch, prev: prev, hadPrev: hadPrev}}
+	}
+}
+
+// saveLabels stores a branch's labels, recording the previous metadata for undo.
+func saveLabels(branch string, labels []string) tea.Cmd {
+	return func() tea.Msg {
+		prev, hadPrev := git.GetWorktreeMeta(branch)
+		next := prev
+		next.Labels = labels
+		if err := git.SetWorktreeMeta(branch, next); err != nil {
+			return worktreeEditedMsg{err: err}
+		}
+		return worktreeEditedMsg{undo: &metaUndoEntry{branch: branch, prev: prev, hadPrev: hadPrev}}
+	}
+}
+
+// saveNotes stores a branch's notes, recording the previous metadata for undo.
+func saveNotes(branch, notes string) tea.Cmd {
+	return func() tea.Msg {
+		prev, hadPrev := git.GetWorktreeMeta(branch)
+		next := prev
+		next.Notes = notes
+		if err := git.SetWorktreeMeta(branch, next); err != nil {
+			return worktreeEditedMsg{err: err}
+		}
+		return worktreeEditedMsg{undo: &metaUndoEntry{branch: branch, prev: prev, hadPrev: hadPrev}}
+	}
+}
+
+func undoMetaEdit(e metaUndoEntry) tea.Cmd {
+	return func() tea.Msg {
+		if !e.hadPrev {
+			return metaUndoneMsg{err: git.DeleteWorktreeMeta(e.branch)}
+		}
+		return metaUndoneMsg{err: git.SetWorktreeMeta(e.branch, e.prev)}
 	}
 }
 
-func renameWorktree(oldName, newName string) tea.Cmd {
-	return func() tea.Msg { return worktreeRenamedMsg{err: git.RenameBranch(oldName, newName)} }
+func moveWorktree(from, to string) tea.Cmd {
+	return func() tea.Msg { return worktreeMovedMsg{to: to, err: git.MoveWorktree(from, to)} }
 }
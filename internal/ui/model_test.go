@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agnishcc/worktree-tui/internal/config"
+	"github.com/agnishcc/worktree-tui/internal/git"
+)
+
+// newTestRepo creates a repo on main with one commit in a temp dir and makes
+// it the working directory for the rest of the test. Git runs with a
+// throwaway HOME and identity so the user's config cannot leak in.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, k := range []string{"GIT_DIR", "GIT_WORK_TREE"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "Test")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "test@example.com")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	chdir(t, dir)
+	return dir
+}
+
+// runGit runs git in dir and fails the test if it does.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestExecResult(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	if exitErr == nil {
+		t.Fatal("sh -c 'exit 3' succeeded")
+	}
+	_, lookErr := exec.LookPath("worktree-tui-no-such-editor")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"clean exit", nil, false},
+		{"non-zero exit", exitErr, false},
+		{"wrapped non-zero exit", errors.Join(errors.New("editor"), exitErr), false},
+		{"not found", lookErr, true},
+		{"other start failure", errors.New("fork/exec: permission denied"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := execResult(tt.err).(execDoneMsg)
+			if !ok {
+				t.Fatalf("execResult returned %T, want execDoneMsg", execResult(tt.err))
+			}
+			if (msg.err != nil) != tt.wantErr {
+				t.Errorf("execResult(%v).err = %v, want error: %v", tt.err, msg.err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecDoneShowsOnlyStartFailures(t *testing.T) {
+	m := InitialModel(config.Default())
+	next, _ := m.Update(execResult(exec.Command("sh", "-c", "exit 1").Run()))
+	if got := next.(Model).errMsg; got != "" {
+		t.Errorf("after a non-zero exit errMsg = %q, want none", got)
+	}
+	next, _ = m.Update(execResult(errors.New("no such editor")))
+	if got := next.(Model).errMsg; got != "no such editor" {
+		t.Errorf("after a start failure errMsg = %q, want the error", got)
+	}
+}
+
+func TestAttachWorktreeKeepsNotesAndLabels(t *testing.T) {
+	dir := newTestRepo(t)
+	runGit(t, dir, "branch", "feat/old")
+	if err := git.SetWorktreeMeta("feat/old", git.WorktreeMeta{
+		Name:        "Old name",
+		CreatedFrom: "abc1234",
+		Notes:       "remember the migration",
+		Labels:      []string{"review"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := WorktreePath(dir, "feat/old")
+	if _, _, err := AttachWorktree("Revived", "feat/old", path, "back again", nil); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := git.GetWorktreeMeta("feat/old")
+	if !ok {
+		t.Fatal("no metadata entry after attaching")
+	}
+	if got.Name != "Revived" || got.Description != "back again" || got.CreatedFrom != "" {
+		t.Errorf("name, description, createdFrom = %q, %q, %q; want the attach form's values and no start point",
+			got.Name, got.Description, got.CreatedFrom)
+	}
+	if got.Notes != "remember the migration" || len(got.Labels) != 1 || got.Labels[0] != "review" {
+		t.Errorf("notes, labels = %q, %v; want them kept", got.Notes, got.Labels)
+	}
+}
+
+func TestPruneWorktreeDeletesMeta(t *testing.T) {
+	dir := newTestRepo(t)
+	path := WorktreePath(dir, "feat/gone")
+	runGit(t, dir, "worktree", "add", "-q", "-b", "feat/gone", path)
+	if err := git.SetWorktreeMeta("feat/gone", git.WorktreeMeta{Name: "Gone", Notes: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := pruneWorktree("feat/gone", path)().(worktreeDeletedMsg)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if _, ok := git.GetWorktreeMeta("feat/gone"); ok {
+		t.Error("metadata entry left behind after pruning the missing worktree")
+	}
+}
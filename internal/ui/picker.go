@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// picker is the type-to-filter list shared by the selection overlays.
+// Items are matched against the query as a case-insensitive subsequence.
+type picker struct {
+	items  []pickerItem
+	query  string
+	cursor int // index into visible()
+}
+
+// pickerItem is one selectable row: Label is matched and shown, Detail is
+// shown dimmed after it.
+type pickerItem struct {
+	Label  string
+	Detail string
+}
+
+// pickerMaxRows caps how many rows the overlay renders.
+const pickerMaxRows = 12
+
+func newPicker(items []pickerItem) picker {
+	return picker{items: items}
+}
+
+// visible returns the indices of items matching the current query.
+func (p picker) visible() []int {
+	var idx []int
+	q := strings.ToLower(p.query)
+	for i, it := range p.items {
+		if fuzzyMatch(strings.ToLower(it.Label), q) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// selected returns the index into items of the highlighted row, or -1.
+func (p picker) selected() int {
+	vis := p.visible()
+	if p.cursor < 0 || p.cursor >= len(vis) {
+		return -1
+	}
+	return vis[p.cursor]
+}
+
+// update applies navigation and query edits. Enter and esc are left to the
+// caller.
+func (p picker) update(msg tea.KeyMsg) picker {
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyCtrlP:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case tea.KeyDown, tea.KeyCtrlN:
+		if p.cursor < len(p.visible())-1 {
+			p.cursor++
+		}
+	case tea.KeyBackspace:
+		p.query = dropLast(p.query)
+		p.cursor = 0
+	case tea.KeySpace:
+		p.query += " "
+		p.cursor = 0
+	case tea.KeyRunes:
+		p.query += string(msg.Runes)
+		p.cursor = 0
+	}
+	return p
+}
+
+// view renders the query line and the visible rows, scrolled so the cursor
+// stays in view.
+func (p picker) view(width int) string {
+	rows := []string{accentStyle.Render("› ") + modalInputStyle.Render(p.query) + accentStyle.Render("█"), ""}
+	vis := p.visible()
+	if len(vis) == 0 {
+		rows = append(rows, dimStyle.Render("no matches"))
+	}
+	start := 0
+	if p.cursor >= pickerMaxRows {
+		start = p.cursor - pickerMaxRows + 1
+	}
+	for i := start; i < len(vis) && i < start+pickerMaxRows; i++ {
+		it := p.items[vis[i]]
+		label := truncate(it.Label, width-2)
+		detail := ""
+		if it.Detail != "" {
+			detail = "  " + dimStyle.Render(truncate(it.Detail, width-4-lipgloss.Width(label)))
+		}
+		if i == p.cursor {
+			rows = append(rows, selectedAccentStyle.Render("▌")+" "+selectedItemStyle.Render(label)+detail)
+		} else {
+			rows = append(rows, "  "+normalItemStyle.Render(label)+detail)
+		}
+	}
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(rows, "\n"))
+}
+
+// fuzzyMatch reports whether every rune of q appears in s in order.
+func fuzzyMatch(s, q string) bool {
+	for _, r := range q {
+		i := strings.IndexRune(s, r)
+		if i == -1 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
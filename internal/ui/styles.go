@@ -49,8 +49,8 @@ var (
 			BorderForeground(clrAccent)
 
 	inactivePaneStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(clrDim)
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(clrDim)
 
 	// activeRightPaneStyle is used when Level 2 focus shifts to the right pane.
 	activeRightPaneStyle = lipgloss.NewStyle().
@@ -81,6 +81,7 @@ var (
 	// ── Footer ────────────────────────────────────────────────────────────────
 	footerStyle    = lipgloss.NewStyle().Foreground(clrDim)
 	footerKeyStyle = lipgloss.NewStyle().Foreground(clrAccent).Bold(true)
+	statusStyle    = lipgloss.NewStyle().Foreground(clrGreen)
 
 	// ── Modals ────────────────────────────────────────────────────────────────
 	modalStyle = lipgloss.NewStyle().
@@ -102,3 +103,26 @@ var (
 	// ── Shell setup ───────────────────────────────────────────────────────────
 	accentStyle = lipgloss.NewStyle().Foreground(clrAccent).Bold(true)
 )
+
+// applyBorder redraws every bordered style with the named border (see
+// config.BorderStyles). It runs at startup, before the first render, and
+// again when the config is reloaded.
+func applyBorder(name string) {
+	var b lipgloss.Border
+	switch name {
+	case "normal":
+		b = lipgloss.NormalBorder()
+	case "thick":
+		b = lipgloss.ThickBorder()
+	case "none":
+		// Blank border cells keep every size calculation unchanged.
+		b = lipgloss.HiddenBorder()
+	default:
+		b = lipgloss.RoundedBorder()
+	}
+	for _, st := range []*lipgloss.Style{
+		&headerBoxStyle, &activePaneStyle, &inactivePaneStyle, &activeRightPaneStyle, &modalStyle,
+	} {
+		*st = st.BorderStyle(b)
+	}
+}
@@ -1,10 +1,16 @@
 package ui
 
 import (
+	"fmt"
+	"maps"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/agnishcc/worktree-tui/internal/config"
 	"github.com/agnishcc/worktree-tui/internal/git"
 	"github.com/agnishcc/worktree-tui/internal/types"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,8 +26,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.WindowSizeMsg:
+		if (m.state != types.StateCommitDetail && m.state != types.StateWorkingDiff) || m.width == 0 {
+			m.width = msg.Width
+			m.height = msg.Height
+			return m, nil
+		}
+		// Keep the same part of the diff in view: scroll by the same fraction
+		// of the (re-wrapped) content as before the resize.
+		oldMax := m.commitDetailMaxScroll()
 		m.width = msg.Width
 		m.height = msg.Height
+		m.commitDetailScroll = rescaleScroll(m.commitDetailScroll, oldMax, m.commitDetailMaxScroll())
 		return m, nil
 
 	case gitCheckMsg:
@@ -29,9 +44,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = types.StateNoGit
 			return m, nil
 		}
-		if git.IsShellIntegrated() {
+		m.enclosingRepo = msg.enclosingRepo
+		// NoShellPrompt skips the prompt without writing the marker, so the
+		// shell is never recorded as integrated when it isn't.
+		if git.IsShellIntegrated() || m.cfg.NoShellPrompt || m.cfg.ReadOnly {
 			m.state = types.StateList
-			return m, loadWorktrees()
+			return m, m.loadWorktrees()
 		}
 		m.state = types.StateShellSetup
 		return m, nil
@@ -41,7 +59,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.errMsg = msg.err.Error()
 			return m, nil
 		}
-		m.worktrees = msg.worktrees
+		m.repoState = msg.repoState
+		m.allWorktrees = msg.worktrees
+		m.userName = msg.userName
+		m.userEmail = msg.userEmail
+		m.worktrees = m.visibleWorktrees()
 		m.repoName = msg.repoName
 		m.curBranch = msg.curBranch
 		m.remoteURL = msg.remoteURL
@@ -52,20 +74,206 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.hasCommits = msg.hasCommits
 		if m.prCache == nil {
 			m.prCache = make(map[string]prCacheEntry)
+			m.prPending = make(map[string]bool)
+			m.protectionPending = make(map[string]bool)
+		}
+		// Reloads run in the background, so they never move the user off the
+		// screen they are on; only the pre-list states give way to the list.
+		// Actions that end on the list set StateList themselves.
+		if m.state == types.StateNoGit || m.state == types.StateShellSetup {
+			m.state = types.StateList
 		}
-		m.state = types.StateList
 		if m.cursor > len(m.worktrees) {
 			m.cursor = len(m.worktrees)
 		}
-		return m, m.maybeFetchPR()
+		if m.selectPath != "" {
+			for i, wt := range m.worktrees {
+				if wt.Path == m.selectPath {
+					m.cursor = i + 1
+				}
+			}
+			m.selectPath = ""
+		}
+		if m.cfg.AutoPruneMerged && !m.pruneOffered && !m.cfg.ReadOnly {
+			m.pruneOffered = true
+			return m, tea.Batch(m.maybeFetchPR(), m.prefetchPRs(), findPruneCandidates(m.mergedClean(), maps.Clone(m.protectedCache), m.ghAvailable))
+		}
+		return m, tea.Batch(m.maybeFetchPR(), m.prefetchPRs())
+
+	case pruneCandidatesMsg:
+		if m.protectedCache == nil {
+			m.protectedCache = make(map[string]bool)
+		}
+		for branch, protected := range msg.protected {
+			m.protectedCache[branch] = protected
+		}
+		if m.state != types.StateList {
+			return m, nil
+		}
+		if len(msg.worktrees) == 0 {
+			if msg.finished {
+				return m, m.setStatus("no finished worktrees")
+			}
+			return m, nil
+		}
+		m.pruneCandidates, m.pruneResults, m.pruneFinished = msg.worktrees, nil, msg.finished
+		m.state = types.StatePruneMerged
+		return m, nil
+
+	case prunedMsg:
+		m.pruneResults = msg.results
+		return m, m.loadWorktrees()
 
 	case prFetchedMsg:
 		if m.prCache == nil {
 			m.prCache = make(map[string]prCacheEntry)
 		}
 		m.prCache[msg.branch] = msg.info
+		delete(m.prPending, msg.branch)
+		return m, nil
+
+	case stashesLoadedMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		m.stashes = msg.entries
+		m.stashCursor = min(m.stashCursor, max(len(m.stashes)-1, 0))
+		return m, nil
+
+	case stashDoneMsg:
+		if msg.err != nil {
+			m.errMsg = lastLine(msg.err.Error())
+			return m, tea.Batch(loadStashes, m.loadWorktrees())
+		}
+		return m, tea.Batch(m.setStatus(msg.verb+" "+msg.ref), loadStashes, m.loadWorktrees())
+
+	case mergePlanMsg:
+		if m.state == types.StateMergeConfirm {
+			m.mergePlan = &msg.plan
+		}
+		return m, nil
+
+	case branchMergedMsg:
+		m.state = types.StateList
+		if msg.err != nil {
+			m.errMsg = "merge failed: " + lastLine(msg.err.Error())
+			return m, m.loadWorktrees()
+		}
+		return m, tea.Batch(m.setStatus("merged "+m.mergeSource.Branch+" into "+m.mergeTarget.Branch), m.loadWorktrees())
+
+	case prListMsg:
+		if m.state != types.StatePRCheckout {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = types.StateList
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		m.prList = msg.prs
+		var items []pickerItem
+		for _, pr := range msg.prs {
+			detail := pr.Branch + " · " + pr.Author
+			for _, wt := range m.allWorktrees {
+				if wt.Branch == pr.LocalBranch() {
+					detail = "→ " + wt.Name
+				}
+			}
+			items = append(items, pickerItem{Label: fmt.Sprintf("#%d %s", pr.Number, pr.Title), Detail: detail})
+		}
+		m.prPicker = newPicker(items)
+		return m, nil
+
+	case prCreatedMsg:
+		m.prCreating = false
+		if msg.err != nil {
+			m.prErr = lastLine(msg.err.Error())
+			return m, nil
+		}
+		// Show the badge now rather than waiting for a refetch.
+		if m.prCache == nil {
+			m.prCache = make(map[string]prCacheEntry)
+		}
+		m.prCache[msg.branch] = msg.info
+		m.state = types.StateList
+		return m, m.setStatus(fmt.Sprintf("opened PR #%d", msg.info.Number))
+
+	case protectionMsg:
+		if m.protectedCache == nil {
+			m.protectedCache = make(map[string]bool)
+		}
+		m.protectedCache[msg.branch] = msg.protected
+		delete(m.protectionPending, msg.branch)
+		return m, nil
+
+	case initialCommitMsg:
+		m.state = types.StateList
+		m.resetNewModal()
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, m.loadWorktrees()
+		}
+		return m, tea.Batch(m.setStatus("created the initial commit"), m.loadWorktrees())
+
+	case copiedMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		return m, m.setStatus("copied " + msg.what)
+
+	case worktreeEnrichedMsg:
+		for i, wt := range m.allWorktrees {
+			if wt.Path == msg.wt.Path {
+				m.allWorktrees[i] = msg.wt
+			}
+		}
+		m.relist()
+		// The merge check needs the branch status that just arrived.
+		return m, m.maybeCheckMerge()
+
+	case mergeCheckedMsg:
+		if m.mergeCache == nil {
+			m.mergeCache = make(map[string]mergeCheck)
+		}
+		m.mergeCache[msg.key] = msg.check
+		return m, nil
+
+	case fileTreeMsg:
+		if msg.path != m.fileTreePath {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			m.state = types.StateList
+			return m, nil
+		}
+		m.fileTree = msg.entries
+		return m, nil
+
+	case branchAgesMsg:
+		m.branchAges = msg.ages
+		return m, nil
+
+	case unmergedCommitsMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		if m.unmergedCache == nil {
+			m.unmergedCache = make(map[string][]types.Commit)
+		}
+		m.unmergedCache[msg.key] = msg.commits
 		return m, nil
 
+	case fileAtRevMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		return m, m.openFile(msg.path, msg.dir)
+
 	case commitDetailLoadedMsg:
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
@@ -74,23 +282,106 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.detail != nil {
 			m.activeCommit = *msg.detail
 		}
+		if m.workingDiffFile >= len(m.activeCommit.Files) {
+			m.workingDiffFile = max(len(m.activeCommit.Files)-1, 0)
+		}
 		return m, nil
 
+	case repoSwitchedMsg:
+		if msg.err != nil {
+			m.state = types.StateList
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		// Start over with a fresh model for the new repo, keeping display prefs.
+		fresh := InitialModel(m.cfg)
+		fresh.width, fresh.height = m.width, m.height
+		fresh.diffAlgorithm = m.diffAlgorithm
+		fresh.diffIgnoreWS = m.diffIgnoreWS
+		return fresh, checkGitRepo
+
 	case gitInitMsg:
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			return m, nil
 		}
 		m.state = types.StateList
-		return m, loadWorktrees()
+		return m, m.loadWorktrees()
 
 	case worktreeCreatedMsg:
 		m.state = types.StateList
 		m.resetNewModal()
+		m.branchTarget = nil
+		m.selectPath = msg.path
+		if msg.hookOut != "" {
+			// Hooks had something to say; show it all rather than one line.
+			m.hookOut, m.hookErr, m.hookPath, m.hookScroll = msg.hookOut, "", msg.path, 0
+			if msg.err != nil {
+				m.hookErr = msg.err.Error()
+			}
+			m.state = types.StateHookOutput
+			return m, m.loadWorktrees()
+		}
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, m.loadWorktrees()
+		}
+		return m, tea.Batch(m.setStatus("created "+filepath.Base(msg.path)), m.loadWorktrees())
+
+	case committedMsg:
+		if msg.err != nil {
+			m.commitErr = lastLine(msg.err.Error()) // git's final line carries the reason
+			return m, nil
+		}
+		m.state = types.StateWorkingDiff
+		m.activeCommit.Loaded = false
+		return m, tea.Batch(m.setStatus("committed"), m.reloadActiveDiff(), m.loadWorktrees())
+
+	case fileStagedMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+		}
+		// Refresh the list's status counts, and the overlay when it is open.
+		if m.state == types.StateWorkingDiff {
+			return m, tea.Batch(m.reloadActiveDiff(), m.loadWorktrees())
+		}
+		return m, m.loadWorktrees()
+
+	case branchFetchedMsg:
+		branch := m.fetchingBranch
+		m.fetchingBranch = ""
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, m.loadWorktrees()
+		}
+		// The reload refreshes the upstream status wherever the user is now,
+		// since F may have been pressed from the commit list.
+		return m, tea.Batch(m.setStatus("fetched "+branch), m.loadWorktrees())
+
+	case branchPushedMsg:
+		branch := m.pushingBranch
+		m.pushingBranch = ""
+		if msg.err != nil {
+			m.errMsg = "push failed: " + lastLine(msg.err.Error()) // git's final line carries the reason
+			return m, nil
+		}
+		return m, tea.Batch(m.setStatus("pushed "+branch+" to origin"), m.loadWorktrees())
+
+	case allFetchedMsg:
+		m.fetchingAll = false
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
+			return m, m.loadWorktrees()
 		}
-		return m, loadWorktrees()
+		// The reload refreshes fetchedAgo and upstream-gone along with the rest.
+		return m, tea.Batch(m.setStatus("fetched all remotes"), m.loadWorktrees())
+
+	case fetchSpinMsg:
+		if !m.fetchingAll {
+			return m, nil
+		}
+		m.fetchSpin++
+		return m, fetchSpinTick()
 
 	case worktreeDeletedMsg:
 		m.state = types.StateList
@@ -100,14 +391,70 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.cursor > 0 {
 			m.cursor--
 		}
-		return m, loadWorktrees()
+		if msg.err == nil {
+			verb := "deleted "
+			if msg.pruned {
+				verb = "pruned "
+			}
+			return m, tea.Batch(m.setStatus(verb+filepath.Base(msg.path)), m.loadWorktrees())
+		}
+		return m, m.loadWorktrees()
+
+	case worktreeEditedMsg:
+		m.state = types.StateList
+		m.resetEditModal()
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+		}
+		if msg.undo != nil {
+			m.metaUndo = append(m.metaUndo, *msg.undo)
+			if len(m.metaUndo) > maxMetaUndo {
+				m.metaUndo = m.metaUndo[len(m.metaUndo)-maxMetaUndo:]
+			}
+		}
+		return m, m.loadWorktrees()
+
+	case execDoneMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+		}
+		// The editor or shell may have changed files or branches.
+		return m, m.loadWorktrees()
 
-	case worktreeRenamedMsg:
+	case repoStateSavedMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+		}
+		return m, nil
+
+	case metaUndoneMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, m.loadWorktrees()
+		}
+		return m, tea.Batch(m.setStatus("undid last edit"), m.loadWorktrees())
+
+	case worktreeMovedMsg:
 		m.state = types.StateList
+		m.moveDest = ""
+		m.moveErr = ""
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
+			return m, m.loadWorktrees()
+		}
+		return m, tea.Batch(m.setStatus("moved to "+msg.to), m.loadWorktrees())
+
+	case statusClearMsg:
+		if msg.seq == m.statusSeq {
+			m.statusMsg = ""
+		}
+		return m, nil
+
+	case quitDisarmMsg:
+		if msg.seq == m.quitSeq {
+			m.quitArmed = false
 		}
-		return m, loadWorktrees()
+		return m, nil
 
 	case tea.KeyMsg:
 		return m.handleKey(msg)
@@ -116,14 +463,123 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// statusTimeout is how long a status-line confirmation stays up.
+const statusTimeout = 3 * time.Second
+
+// setStatus shows s in the status line and returns the tick that clears it.
+// A newer status replaces an older one and outlives its tick.
+func (m *Model) setStatus(s string) tea.Cmd {
+	m.statusMsg = s
+	m.statusSeq++
+	seq := m.statusSeq
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg { return statusClearMsg{seq} })
+}
+
+// quitConfirmWindow is how long a first ctrl+c waits for the second.
+const quitConfirmWindow = 2 * time.Second
+
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if msg.String() == "ctrl+c" {
-		return m, tea.Quit
+		if m.cfg.InstantQuit || m.quitArmed {
+			return m, tea.Quit
+		}
+		m.quitArmed = true
+		m.quitSeq++
+		seq := m.quitSeq
+		return m, tea.Tick(quitConfirmWindow, func(time.Time) tea.Msg { return quitDisarmMsg{seq} })
 	}
+	m.quitArmed = false
 	if m.errMsg != "" {
 		m.errMsg = ""
 		return m, nil
 	}
+	if msg.String() == "?" && modalHelp(m) != nil && !m.typingText() {
+		m.modalHelpOpen = !m.modalHelpOpen
+		return m, nil
+	}
+	next, cmd := m.dispatchKey(msg)
+	if nm, ok := next.(Model); ok && (nm.state != m.state || nm.newTypeListOpen != m.newTypeListOpen) {
+		nm.modalHelpOpen = false
+		next = nm
+	}
+	return next, cmd
+}
+
+// jumpToDirty moves the cursor to the next (or previous) worktree with
+// uncommitted changes, wrapping around the list.
+func (m Model) jumpToDirty(forward bool) (tea.Model, tea.Cmd) {
+	n := len(m.worktrees)
+	step := n - 1
+	if forward {
+		step = 1
+	}
+	start := m.cursor - 1 // -1 on the "+ new worktree" row
+	if start < 0 && !forward {
+		start = 0
+	}
+	for i := 1; i <= n; i++ {
+		idx := ((start+i*step)%n + n) % n
+		if wt := m.worktrees[idx]; wt.StatusChanged+wt.StatusUntracked > 0 {
+			if idx == m.cursor-1 {
+				break // the only dirty one is already selected
+			}
+			m.cursor = idx + 1
+			return m, m.maybeFetchPR()
+		}
+	}
+	return m, m.setStatus("no other dirty worktrees")
+}
+
+// typingText reports whether ? should go to the focused text field rather
+// than toggle the modal help: it does once the field has text in it, except
+// for branch names and paths, where ? is never wanted.
+func (m Model) typingText() bool {
+	switch m.state {
+	case types.StateNewWorktree:
+		switch {
+		case m.newTypeListOpen:
+			return false
+		case m.newBranchOpen:
+			return m.newBranchPicker.query != ""
+		case m.newActiveField == 1:
+			return m.newDisplayName != ""
+		case m.newActiveField == 3:
+			return m.newDescription != ""
+		}
+	case types.StateEditWorktree:
+		switch m.editActiveField {
+		case 1:
+			return m.editDisplayName != ""
+		case 3:
+			return m.editDescription != ""
+		}
+	case types.StateNotes:
+		return m.notesDraft != ""
+	case types.StateLabels:
+		return m.labelsDraft != ""
+	case types.StateLabelFilter:
+		return m.labelPicker.query != ""
+	case types.StateCommitMessage:
+		if m.commitActiveField == 0 {
+			return m.commitSubject != ""
+		}
+		return m.commitBody != ""
+	case types.StateCreatePR:
+		if m.prActiveField == 0 {
+			return m.prTitle != ""
+		}
+		return m.prBody != ""
+	case types.StateRepoSwitch:
+		return m.repoPicker.query != ""
+	case types.StateBranchSwitch:
+		return m.branchTarget == nil && m.branchPicker.query != ""
+	case types.StatePRCheckout:
+		return m.prPicker.query != ""
+	}
+	return false
+}
+
+func (m Model) dispatchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch m.state {
 	case types.StateNoGit:
 		return m.handleNoGit(msg)
@@ -137,10 +593,46 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleEditWorktree(msg)
 	case types.StateDeleteConfirm:
 		return m.handleDeleteConfirm(msg)
+	case types.StateMoveWorktree:
+		return m.handleMoveWorktree(msg)
+	case types.StateNotes:
+		return m.handleNotes(msg)
+	case types.StateRepoSwitch:
+		return m.handleRepoSwitch(msg)
+	case types.StateBranchSwitch:
+		return m.handleBranchSwitch(msg)
 	case types.StateRightPaneFocused:
 		return m.handleRightPaneFocused(msg)
-	case types.StateCommitDetail:
+	case types.StateCommitDetail, types.StateWorkingDiff:
 		return m.handleCommitDetail(msg)
+	case types.StateActivity:
+		return m.handleActivity(msg)
+	case types.StateBranchAges:
+		return m.handleBranchAges(msg)
+	case types.StateFileTree:
+		return m.handleFileTree(msg)
+	case types.StateCDConfirm:
+		return m.handleCDConfirm(msg)
+	case types.StateCommitMessage:
+		return m.handleCommitMessage(msg)
+	case types.StateCreatePR:
+		return m.handleCreatePR(msg)
+	case types.StatePRCheckout:
+		return m.handlePRCheckout(msg)
+	case types.StateMergeConfirm:
+		return m.handleMergeConfirm(msg)
+	case types.StateStash:
+		return m.handleStash(msg)
+	case types.StateGitLink:
+		return m.handleGitLink(msg)
+	case types.StateHookOutput:
+		return m.handleHookOutput(msg)
+	case types.StatePruneMerged:
+		return m.handlePruneMerged(msg)
+	case types.StateLabels:
+		return m.handleLabels(msg)
+	case types.StateLabelFilter:
+		return m.handleLabelFilter(msg)
 	}
 	return m, nil
 }
@@ -148,6 +640,10 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleNoGit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "i":
+		if m.cfg.ReadOnly {
+			m.errMsg = readOnlyNotice
+			return m, nil
+		}
 		return m, initGitRepo
 	case "q":
 		return m, tea.Quit
@@ -161,29 +657,57 @@ func (m Model) handleShellSetup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		_ = git.SetupShellIntegration()
 		_ = git.MarkShellIntegrated()
 		m.state = types.StateList
-		return m, loadWorktrees()
+		return m, m.loadWorktrees()
 	case "n", "esc", "q":
 		_ = git.MarkShellIntegrated()
 		m.state = types.StateList
-		return m, loadWorktrees()
+		return m, m.loadWorktrees()
 	}
 	return m, nil
 }
 
+// readOnlyNotice is shown when a mutating key is pressed in read-only mode.
+const readOnlyNotice = "read-only mode — action disabled"
+
+// shellFallbackNote explains why c also copied or printed a cd command.
+const shellFallbackNote = "no wt() shell wrapper was found in your shell rc file; if your shell did not cd, run the command above or enable shell integration"
+
+// mutatingListKeys are the StateList keys that change the repo or its
+// metadata and are rejected in read-only mode.
+var mutatingListKeys = map[string]bool{
+	"n": true, "d": true, "e": true, "m": true, "N": true, "u": true, "*": true,
+	"+": true, "-": true, "L": true, "E": true, "p": true, "D": true, "X": true, "x": true,
+	"P": true, "C": true, "M": true,
+}
+
 func (m Model) handleList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	total := len(m.worktrees) + 1
+	if prefix := m.listPrefix; prefix != "" {
+		m.listPrefix = ""
+		if msg.String() == "d" {
+			return m.jumpToDirty(prefix == "]")
+		}
+	}
+	if m.onGroupHeader() {
+		switch msg.String() {
+		case "z", "enter":
+			m.toggleGroup()
+			return m, m.maybeFetchPR()
+		}
+	}
+	if m.cfg.ReadOnly && (mutatingListKeys[msg.String()] || (msg.String() == "enter" && m.cursor == 0)) {
+		m.errMsg = readOnlyNotice
+		return m, nil
+	}
 	switch msg.String() {
 	case "q":
 		return m, tea.Quit
+	case "ctrl+r":
+		return m.reloadConfig()
 	case "up", "k":
-		if m.cursor > 0 {
-			m.cursor--
-		}
+		m.moveCursor(-1)
 		return m, m.maybeFetchPR()
 	case "down", "j":
-		if m.cursor < total-1 {
-			m.cursor++
-		}
+		m.moveCursor(1)
 		return m, m.maybeFetchPR()
 	case "enter":
 		if m.cursor == 0 {
@@ -191,30 +715,550 @@ func (m Model) handleList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		} else if m.cursor-1 < len(m.worktrees) {
 			m.selectedCommitIndex = 0
 			m.state = types.StateRightPaneFocused
+			m.touch(m.worktrees[m.cursor-1].Branch)
+			return m, saveRepoState(m.repoState)
 		}
+	case "]", "[":
+		m.listPrefix = msg.String()
 	case "n":
 		m.openNewModal()
+	case "F":
+		return m.startBranchFetch()
+	case "ctrl+f":
+		// f already opens the file tree, so fetching every remote takes ctrl+f.
+		if m.fetchingAll {
+			return m, nil
+		}
+		m.fetchingAll, m.fetchSpin = true, 0
+		return m, tea.Batch(fetchAll(), fetchSpinTick())
+	case "A":
+		m.activity = buildActivity(m.allWorktrees)
+		m.activityCursor = 0
+		m.state = types.StateActivity
+	case "f":
+		if m.cursor > 0 {
+			m.fileTree, m.fileTreeScroll = nil, 0
+			m.fileTreePath = m.worktrees[m.cursor-1].Path
+			m.state = types.StateFileTree
+			return m, loadFileTree(m.fileTreePath)
+		}
+	case "S":
+		m.branchAges, m.branchAgesScroll = nil, 0
+		m.state = types.StateBranchAges
+		return m, loadBranchAges(m.allWorktrees, m.compareBranch())
+	case "a":
+		m.mineOnly = !m.mineOnly
+		m.relist()
+		return m, m.maybeFetchPR()
+	case "B":
+		if m.cursor > 0 {
+			wt := m.worktrees[m.cursor-1]
+			if wt.Unborn || strings.HasPrefix(wt.Branch, "(") {
+				m.errMsg = "only a branch with commits can be the compare target"
+				return m, nil
+			}
+			return m.setCompareBranch(wt.Branch)
+		}
+	case "b":
+		return m.setCompareBranch("")
+	case "g":
+		m.grouped = !m.grouped
+		m.relist()
+		return m, m.maybeFetchPR()
+	case "z":
+		if m.grouped {
+			m.toggleGroup()
+			return m, m.maybeFetchPR()
+		}
+	case "Z":
+		if m.grouped && len(m.collapsedGroups) > 0 {
+			m.collapsedGroups = nil
+			m.relist()
+			return m, m.maybeFetchPR()
+		}
+	case "v":
+		if m.cursor > 0 {
+			wt := m.worktrees[m.cursor-1]
+			m.activeCommit = types.CommitDetail{Subject: "Uncommitted changes"}
+			m.commitDetailScroll = 0
+			m.workingDiffFile = 0
+			m.activeCommitPath = wt.Path
+			m.overlayReturn = m.state
+			m.state = types.StateWorkingDiff
+			return m, loadWorkingDiff(wt.Path, m.diffOptions())
+		}
 	case "d":
 		if m.cursor > 0 && !m.worktrees[m.cursor-1].IsMain {
+			if branch := m.worktrees[m.cursor-1].Branch; m.protectedCache[branch] {
+				m.errMsg = branch + " is a protected branch — not deleting its worktree"
+				return m, nil
+			} else if cmd := m.maybeFetchProtection(); cmd != nil || m.protectionPending[branch] {
+				// Until GitHub answers, the branch may be protected.
+				m.errMsg = "still checking whether " + branch + " is protected — try again in a moment"
+				return m, cmd
+			}
 			m.state = types.StateDeleteConfirm
 		}
+	case "D":
+		if m.cursor > 0 {
+			wt := m.worktrees[m.cursor-1]
+			if wt.Unborn || wt.Missing {
+				m.errMsg = wt.Name + " has no commit to start from"
+				return m, nil
+			}
+			if err := m.openDuplicateModal(wt); err != nil {
+				m.errMsg = err.Error()
+			}
+		}
+	case "p":
+		if m.cursor > 0 && m.worktrees[m.cursor-1].Missing {
+			m.errMsg = m.worktrees[m.cursor-1].Name + "'s directory is missing — nothing to push; x prunes the entry"
+			return m, nil
+		}
+		return m.startPush()
+	case "x":
+		if m.cursor > 0 && m.worktrees[m.cursor-1].Missing {
+			wt := m.worktrees[m.cursor-1]
+			return m, pruneWorktree(wt.Branch, wt.Path)
+		}
+	case "P":
+		return m.openCreatePR()
+	case "M":
+		if m.cursor > 0 {
+			return m.openMergeConfirm(m.worktrees[m.cursor-1])
+		}
+	case "$":
+		return m.openStashes()
+	case "C":
+		if !m.ghAvailable {
+			return m, nil
+		}
+		m.prList, m.prPicker = nil, newPicker(nil)
+		m.prWarnings, m.prWarnedFor = nil, 0
+		m.state = types.StatePRCheckout
+		return m, listOpenPRs
+	case "X":
+		wts := m.finished()
+		if len(wts) == 0 {
+			return m, m.setStatus("no finished worktrees")
+		}
+		return m, findFinished(wts, maps.Clone(m.protectedCache), m.ghAvailable)
 	case "e":
 		if m.cursor > 0 {
-			m.editName = m.worktrees[m.cursor-1].Branch
+			wt := m.worktrees[m.cursor-1]
+			m.resetEditModal()
+			m.editDisplayName = wt.Name
+			m.editBranch = wt.Branch
+			m.editTypeIdx = branchTypeIndex(wt.Branch)
+			m.editDescription = wt.Description
 			m.state = types.StateEditWorktree
 		}
+	case "r":
+		return m.openRepoSwitch()
+	case "i":
+		if m.cursor > 0 {
+			m.gitLink = git.GetWorktreeLink(m.worktrees[m.cursor-1].Path)
+			m.state = types.StateGitLink
+		}
+	case "R":
+		if m.enclosingRepo != "" {
+			return m, switchRepo(m.enclosingRepo)
+		}
+	case "w":
+		return m.openBranchSwitch()
+	case "*":
+		if m.cursor > 0 {
+			m.togglePin(m.worktrees[m.cursor-1].Branch)
+			return m, saveRepoState(m.repoState)
+		}
+	case "N":
+		if m.cursor > 0 {
+			m.notesDraft = m.worktrees[m.cursor-1].Notes
+			m.state = types.StateNotes
+		}
+	case "L":
+		if m.cursor > 0 {
+			m.labelsDraft = strings.Join(m.worktrees[m.cursor-1].Labels, ", ")
+			m.state = types.StateLabels
+		}
+	case "l":
+		return m.openLabelFilter()
+	case "u":
+		if n := len(m.metaUndo); n > 0 {
+			e := m.metaUndo[n-1]
+			m.metaUndo = m.metaUndo[:n-1]
+			return m, undoMetaEdit(e)
+		}
+	case "m":
+		// The main worktree cannot be moved by git.
+		if m.cursor > 0 && !m.worktrees[m.cursor-1].IsMain {
+			m.moveDest = m.worktrees[m.cursor-1].Path
+			m.moveErr = ""
+			m.state = types.StateMoveWorktree
+		}
 	case "c":
 		if m.cursor > 0 {
-			_ = git.WriteCDPath(m.worktrees[m.cursor-1].Path)
-			return m, tea.Quit
+			wt := m.worktrees[m.cursor-1]
+			if m.cfg.ConfirmCD {
+				m.state = types.StateCDConfirm
+				return m, nil
+			}
+			return m.cdTo(wt)
 		}
-	}
-	return m, nil
-}
+	case "+", "-":
+		if m.cursor > 0 {
+			return m, stageAll(m.worktrees[m.cursor-1].Path, msg.String() == "-")
+		}
+	case "s":
+		m.repoState.SortMode = nextSortMode(m.repoState.SortMode)
+		m.relist()
+		return m, tea.Batch(saveRepoState(m.repoState), m.maybeFetchPR())
+	case "o":
+		if m.cursor > 0 {
+			return m, openInEditor(m.worktrees[m.cursor-1].Path)
+		}
+	case "E":
+		if m.cursor > 0 {
+			wt := m.worktrees[m.cursor-1]
+			if wt.Branch == "(detached)" {
+				m.errMsg = "scratchpads are kept per branch; this worktree is detached"
+				return m, nil
+			}
+			return m, editScratchpad(wt.Path, wt.Branch)
+		}
+	case "t":
+		if m.cursor > 0 {
+			wt := m.worktrees[m.cursor-1]
+			return m, m.openTerminal(wt.Path, wt.Name)
+		}
+	case "O", "T":
+		// Repo-level entry point, independent of the selected row.
+		root, err := git.GetRepoRoot()
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		if msg.String() == "O" {
+			return m, openInEditor(root)
+		}
+		return m, m.openTerminal(root, filepath.Base(root))
+	}
+	return m, nil
+}
+
+// isPinned reports whether branch is pinned.
+func (m Model) isPinned(branch string) bool {
+	for _, b := range m.repoState.Pinned {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// togglePin pins or unpins branch and re-orders the list, keeping the cursor
+// on the same worktree.
+func (m *Model) togglePin(branch string) {
+	if m.isPinned(branch) {
+		var kept []string
+		for _, b := range m.repoState.Pinned {
+			if b != branch {
+				kept = append(kept, b)
+			}
+		}
+		m.repoState.Pinned = kept
+	} else {
+		m.repoState.Pinned = append(m.repoState.Pinned, branch)
+	}
+	m.relist()
+}
+
+// relist recomputes the visible list, keeping the cursor on the same
+// worktree when it is still shown.
+func (m *Model) relist() {
+	selected := ""
+	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
+		selected = m.worktrees[m.cursor-1].Path
+	}
+	m.worktrees = m.visibleWorktrees()
+	if m.cursor > len(m.worktrees) {
+		m.cursor = len(m.worktrees)
+	}
+	for i, wt := range m.worktrees {
+		if wt.Path == selected {
+			m.cursor = i + 1
+		}
+	}
+}
+
+// visibleWorktrees applies the filters, pin order and, when grouping by
+// type, the group order and collapsed groups to allWorktrees.
+func (m Model) visibleWorktrees() []types.Worktree {
+	wts := m.orderWorktrees(m.filteredWorktrees())
+	if m.grouped {
+		wts = m.groupWorktrees(wts)
+	}
+	return wts
+}
+
+// filteredWorktrees is allWorktrees minus those the author and label
+// filters hide, in git's order.
+func (m Model) filteredWorktrees() []types.Worktree {
+	var wts []types.Worktree
+	for _, wt := range m.allWorktrees {
+		if m.mineOnly && !wt.IsMain && !m.isMine(wt) {
+			continue
+		}
+		if m.labelFilter != "" && !wt.IsMain && !hasLabel(wt, m.labelFilter) {
+			continue
+		}
+		wts = append(wts, wt)
+	}
+	return wts
+}
+
+// otherGroup holds branches without a recognised type prefix.
+const otherGroup = "other"
+
+// groupOrder lists the type groups in display order: branchTypes, then any
+// further types the templates use, then otherGroup.
+func (m Model) groupOrder() []string {
+	order := append([]string(nil), branchTypes...)
+	for _, t := range m.cfg.Templates {
+		if t.Type != "" && !containsString(order, t.Type) {
+			order = append(order, t.Type)
+		}
+	}
+	return append(order, otherGroup)
+}
+
+// branchGroup is the group wt is listed under when grouping by type: its
+// branch's type prefix, otherGroup without a known one, or "" for the main
+// worktree, which stays above the groups.
+func (m Model) branchGroup(wt types.Worktree) string {
+	if wt.IsMain {
+		return ""
+	}
+	if prefix, _, ok := strings.Cut(wt.Branch, "/"); ok && containsString(m.groupOrder(), prefix) {
+		return prefix
+	}
+	return otherGroup
+}
+
+// groupWorktrees stable-sorts wts into groupOrder, keeping the order within
+// each group, and drops the worktrees of collapsed groups.
+func (m Model) groupWorktrees(wts []types.Worktree) []types.Worktree {
+	rank := map[string]int{"": -1}
+	for i, g := range m.groupOrder() {
+		rank[g] = i
+	}
+	grouped := make([]types.Worktree, 0, len(wts))
+	for _, wt := range wts {
+		if !m.collapsedGroups[m.branchGroup(wt)] {
+			grouped = append(grouped, wt)
+		}
+	}
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return rank[m.branchGroup(grouped[i])] < rank[m.branchGroup(grouped[j])]
+	})
+	return grouped
+}
+
+// groupSizes counts the filtered worktrees in each type group, collapsed
+// or not, for the group headers.
+func (m Model) groupSizes() map[string]int {
+	sizes := map[string]int{}
+	for _, wt := range m.filteredWorktrees() {
+		sizes[m.branchGroup(wt)]++
+	}
+	return sizes
+}
+
+// toggleGroup collapses the selected worktree's type group, leaving the
+// cursor on its header, or expands the group whose header is selected,
+// moving the cursor to its first worktree.
+func (m *Model) toggleGroup() {
+	if m.onGroupHeader() {
+		g := m.groupCursor
+		delete(m.collapsedGroups, g)
+		m.groupCursor = ""
+		m.relist()
+		for i, wt := range m.worktrees {
+			if m.branchGroup(wt) == g {
+				m.cursor = i + 1
+				break
+			}
+		}
+		return
+	}
+	if m.cursor == 0 || m.cursor-1 >= len(m.worktrees) {
+		return
+	}
+	g := m.branchGroup(m.worktrees[m.cursor-1])
+	if g == "" {
+		return
+	}
+	if m.collapsedGroups == nil {
+		m.collapsedGroups = map[string]bool{}
+	}
+	m.collapsedGroups[g] = true
+	m.relist()
+	m.cursor, m.groupCursor = 0, g
+}
+
+// onGroupHeader reports whether the cursor is on a collapsed group's
+// header rather than a row; m.cursor is 0 meanwhile, so per-worktree
+// actions do nothing there.
+func (m Model) onGroupHeader() bool {
+	g := m.groupCursor
+	return m.cursor == 0 && g != "" && m.grouped && m.collapsedGroups[g] && m.groupSizes()[g] > 0
+}
+
+// listStop is one cursor position in the list: a row (cursor as in
+// m.cursor) or, with group set, a collapsed group's header.
+type listStop struct {
+	cursor int
+	group  string
+}
+
+// listStops lists the cursor positions top to bottom: "+ new worktree",
+// the worktrees and, when grouping, each collapsed group's header in its
+// place among them.
+func (m Model) listStops() []listStop {
+	stops := []listStop{{}}
+	if !m.grouped {
+		for i := range m.worktrees {
+			stops = append(stops, listStop{cursor: i + 1})
+		}
+		return stops
+	}
+	sizes := m.groupSizes()
+	i := 0
+	for _, g := range append([]string{""}, m.groupOrder()...) {
+		if g != "" && m.collapsedGroups[g] && sizes[g] > 0 {
+			stops = append(stops, listStop{group: g})
+		}
+		for ; i < len(m.worktrees) && m.branchGroup(m.worktrees[i]) == g; i++ {
+			stops = append(stops, listStop{cursor: i + 1})
+		}
+	}
+	return stops
+}
+
+// moveCursor moves the cursor delta stops up or down the list, stopping at
+// either end.
+func (m *Model) moveCursor(delta int) {
+	stops := m.listStops()
+	cur := 0
+	onHeader := m.onGroupHeader()
+	for i, s := range stops {
+		if (onHeader && s.group == m.groupCursor) || (!onHeader && s.group == "" && s.cursor == m.cursor) {
+			cur = i
+			break
+		}
+	}
+	next := cur + delta
+	if next < 0 || next >= len(stops) {
+		return
+	}
+	m.cursor, m.groupCursor = stops[next].cursor, stops[next].group
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLabel(wt types.Worktree, label string) bool {
+	for _, l := range wt.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// isMine reports whether wt's latest commit was authored by the configured
+// user, matching on email first and name as a fallback.
+func (m Model) isMine(wt types.Worktree) bool {
+	if m.userEmail != "" && strings.EqualFold(wt.LastAuthorEmail, m.userEmail) {
+		return true
+	}
+	return m.userName != "" && wt.LastAuthor == m.userName
+}
+
+// orderWorktrees puts pinned worktrees first, in pin order, followed by the
+// rest in the current sort mode's order.
+func (m Model) orderWorktrees(wts []types.Worktree) []types.Worktree {
+	ordered := make([]types.Worktree, 0, len(wts))
+	for _, b := range m.repoState.Pinned {
+		for _, wt := range wts {
+			if wt.Branch == b {
+				ordered = append(ordered, wt)
+			}
+		}
+	}
+	var rest []types.Worktree
+	for _, wt := range wts {
+		if !m.isPinned(wt.Branch) {
+			rest = append(rest, wt)
+		}
+	}
+	switch m.repoState.SortMode {
+	case sortCommitted:
+		sort.SliceStable(rest, func(i, j int) bool { return lastCommitTime(rest[i]) > lastCommitTime(rest[j]) })
+	case sortAccessed:
+		at := m.repoState.LastAccessed
+		sort.SliceStable(rest, func(i, j int) bool { return at[rest[i].Branch] > at[rest[j].Branch] })
+	}
+	return append(ordered, rest...)
+}
+
+// Sort modes for the worktree list, cycled with s.
+const (
+	sortDefault   = ""          // git's order
+	sortCommitted = "committed" // most recent commit first
+	sortAccessed  = "accessed"  // most recently focused or cd'd into first
+)
+
+var sortModes = []string{sortDefault, sortCommitted, sortAccessed}
+
+func nextSortMode(cur string) string {
+	for i, s := range sortModes {
+		if s == cur {
+			return sortModes[(i+1)%len(sortModes)]
+		}
+	}
+	return sortDefault
+}
+
+func lastCommitTime(wt types.Worktree) int64 {
+	if len(wt.Commits) == 0 {
+		return 0
+	}
+	return wt.Commits[0].Time
+}
+
+// touch records branch as accessed now.
+func (m *Model) touch(branch string) {
+	if m.repoState.LastAccessed == nil {
+		m.repoState.LastAccessed = make(map[string]int64)
+	}
+	m.repoState.LastAccessed[branch] = time.Now().Unix()
+}
 
 // maybeFetchPR fires a PR fetch for the currently selected worktree if it
-// hasn't been fetched yet and gh is available.
+// hasn't been fetched yet and gh is available. It also starts the
+// selection's other lazy loads: its details in lazy mode, and its merge check.
 func (m Model) maybeFetchPR() tea.Cmd {
+	return tea.Batch(m.prFetch(), m.maybeFetchProtection(), m.maybeEnrich(), m.maybeCheckMerge(), m.maybeLoadUnmerged())
+}
+
+func (m Model) prFetch() tea.Cmd {
 	if !m.ghAvailable || m.cursor == 0 || m.cursor-1 >= len(m.worktrees) {
 		return nil
 	}
@@ -222,23 +1266,230 @@ func (m Model) maybeFetchPR() tea.Cmd {
 	if wt.IsMain {
 		return nil
 	}
-	if _, cached := m.prCache[wt.Branch]; cached {
+	if _, cached := m.prCache[wt.Branch]; cached || m.prPending[wt.Branch] {
 		return nil
 	}
+	// The map is shared with the model Update returns, so this marks the
+	// fetch in flight there too.
+	m.prPending[wt.Branch] = true
 	return fetchPR(wt.Branch)
 }
 
+// prefetchPRs fetches the PR badge of every non-main worktree that has none
+// yet, when the prefetchPRs setting is on. fetchPR bounds how many run at
+// once.
+func (m Model) prefetchPRs() tea.Cmd {
+	if !m.ghAvailable || !m.cfg.PrefetchPRs {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, wt := range m.allWorktrees {
+		if _, cached := m.prCache[wt.Branch]; wt.IsMain || cached || m.prPending[wt.Branch] {
+			continue
+		}
+		m.prPending[wt.Branch] = true
+		cmds = append(cmds, fetchPR(wt.Branch))
+	}
+	return tea.Batch(cmds...)
+}
+
+// prProgress counts the non-main branches whose PR status has resolved,
+// out of all of them.
+func (m Model) prProgress() (done, total int) {
+	seen := make(map[string]bool)
+	for _, wt := range m.allWorktrees {
+		if wt.IsMain || seen[wt.Branch] {
+			continue
+		}
+		seen[wt.Branch] = true
+		total++
+		if _, ok := m.prCache[wt.Branch]; ok {
+			done++
+		}
+	}
+	return done, total
+}
+
+// maybeEnrich loads the selected worktree's deferred details in lazy mode.
+func (m Model) maybeEnrich() tea.Cmd {
+	if m.cursor == 0 || m.cursor-1 >= len(m.worktrees) || m.worktrees[m.cursor-1].Enriched {
+		return nil
+	}
+	return enrichWorktree(m.worktrees[m.cursor-1], m.compareBranchOverride)
+}
+
+// maybeFetchProtection asks GitHub whether the selected worktree's branch
+// is protected, once per branch.
+func (m Model) maybeFetchProtection() tea.Cmd {
+	if !m.ghAvailable || m.cursor == 0 || m.cursor-1 >= len(m.worktrees) {
+		return nil
+	}
+	wt := m.worktrees[m.cursor-1]
+	if wt.Unborn || strings.HasPrefix(wt.Branch, "(") {
+		return nil
+	}
+	if _, cached := m.protectedCache[wt.Branch]; cached || m.protectionPending[wt.Branch] {
+		return nil
+	}
+	// The map is shared with the model Update returns, so this marks the
+	// lookup in flight there too.
+	m.protectionPending[wt.Branch] = true
+	return fetchProtection(wt.Branch)
+}
+
+// maybeCheckMerge test-merges the selected worktree's branch into the default
+// branch unless that was already done at its current HEAD. Branches with
+// nothing to merge are skipped.
+func (m Model) maybeCheckMerge() tea.Cmd {
+	if m.cursor == 0 || m.cursor-1 >= len(m.worktrees) {
+		return nil
+	}
+	wt := m.worktrees[m.cursor-1]
+	key, ok := m.mergeKey(wt)
+	if !ok {
+		return nil
+	}
+	if _, cached := m.mergeCache[key]; cached {
+		return nil
+	}
+	return checkMerge(key, wt.Path, wt.Branch, m.compareBranch())
+}
+
+// maybeLoadUnmerged fetches the selected worktree's unmerged commits when
+// the right pane lists only those and they are not cached yet.
+func (m Model) maybeLoadUnmerged() tea.Cmd {
+	if !m.unmergedOnly || m.cursor == 0 || m.cursor-1 >= len(m.worktrees) {
+		return nil
+	}
+	wt := m.worktrees[m.cursor-1]
+	key, ok := m.unmergedKey(wt)
+	if !ok {
+		return nil
+	}
+	if _, cached := m.unmergedCache[key]; cached {
+		return nil
+	}
+	return loadUnmergedCommits(key, wt.Path, m.compareBranch())
+}
+
+// unmergedKey identifies a worktree's unmerged commits by path, HEAD and
+// compare branch. ok is false until its HEAD is known, or without a branch
+// to compare against.
+func (m Model) unmergedKey(wt types.Worktree) (key string, ok bool) {
+	if wt.HeadSHA == "" || m.compareBranch() == "" {
+		return "", false
+	}
+	return wt.Path + "@" + wt.HeadSHA + ".." + m.compareBranch(), true
+}
+
+// shownCommits is the commit list the right pane shows for wt: all recent
+// commits, or in unmergedOnly mode those not on the default branch. loaded
+// is false while the latter are being fetched.
+func (m Model) shownCommits(wt types.Worktree) (commits []types.Commit, loaded bool) {
+	if !m.unmergedOnly {
+		return wt.Commits, true
+	}
+	key, ok := m.unmergedKey(wt)
+	if !ok {
+		return nil, false
+	}
+	commits, loaded = m.unmergedCache[key]
+	return commits, loaded
+}
+
+// compareBranch is the branch sync status is measured against: the
+// override set with B, else the default branch.
+func (m Model) compareBranch() string {
+	if m.compareBranchOverride != "" {
+		return m.compareBranchOverride
+	}
+	return m.defaultBranch
+}
+
+// setCompareBranch makes branch the compare target ("" or the default
+// branch clears the override) and reloads so every worktree's status is
+// measured against it.
+func (m Model) setCompareBranch(branch string) (tea.Model, tea.Cmd) {
+	if branch == m.defaultBranch {
+		branch = ""
+	}
+	if branch == m.compareBranchOverride {
+		return m, nil
+	}
+	m.compareBranchOverride = branch
+	status := "comparing against " + m.compareBranch()
+	return m, tea.Batch(m.setStatus(status), m.loadWorktrees())
+}
+
+// mergeKey identifies a merge check by branch, HEAD and compare branch, so
+// new commits or a new target get a fresh one. ok is false for worktrees
+// with nothing to merge.
+func (m Model) mergeKey(wt types.Worktree) (key string, ok bool) {
+	if wt.IsMain || wt.Unborn || wt.Ahead == 0 || wt.HeadSHA == "" ||
+		m.compareBranch() == "" || wt.Branch == m.compareBranch() || strings.HasPrefix(wt.Branch, "(") {
+		return "", false
+	}
+	return wt.Branch + "@" + wt.HeadSHA + ".." + m.compareBranch(), true
+}
+
 func (m *Model) openNewModal() {
 	m.resetNewModal()
 	m.state = types.StateNewWorktree
 }
 
+// openDuplicateModal opens the new-worktree form seeded to branch off wt's
+// current HEAD, with wt's type and a "-copy" name.
+func (m *Model) openDuplicateModal(wt types.Worktree) error {
+	sha, err := git.GetHeadSHA(wt.Path)
+	if err != nil {
+		return fmt.Errorf("%s has no commit to start from: %w", wt.Name, err)
+	}
+	m.openNewModal()
+	m.newBase = sha
+	m.newBaseFrom = wt.Branch + " at " + sha
+	if i := branchTypeIndex(wt.Branch); i >= 0 {
+		_, rest, _ := strings.Cut(wt.Branch, "/")
+		m.newTypeIdx = i
+		m.newDisplayName = rest + "-copy"
+		m.recalcBranch()
+	} else {
+		m.newDisplayName = wt.Branch + "-copy"
+		m.newBranch = slugify(m.newDisplayName, m.cfg.Slug)
+		m.newBranchEdited = true
+	}
+	m.newActiveField = 1
+	return nil
+}
+
+// newWorktreeTarget returns where the form's worktree will be created and
+// its description, with any template applied. Without a repo root every
+// path would come out relative to wherever the tool runs, so that is an
+// error.
+func (m Model) newWorktreeTarget() (wtPath, description string, err error) {
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return "", "", fmt.Errorf("cannot place the worktree: repo root not found: %w", err)
+	}
+	wtPath = WorktreePath(root, m.newBranch)
+	description = m.newDescription
+	if t := m.newTemplate; t != nil {
+		if t.Path != "" {
+			wtPath = git.ExpandPath(t.Expand(t.Path, root, m.newDisplayName, m.newBranch), root)
+		}
+		description = t.Expand(description, root, m.newDisplayName, m.newBranch)
+	}
+	return wtPath, description, nil
+}
+
 // handleNewWorktree dispatches to the type-list handler when the overlay is
-// open, otherwise manages the four-field form.
+// open, otherwise manages the five-field form.
 func (m Model) handleNewWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// In the no-commits error state only esc/q is meaningful.
+	// With no commits yet the modal offers to make the initial one.
 	if !m.hasCommits {
-		if msg.Type == tea.KeyEsc || msg.String() == "q" {
+		switch msg.String() {
+		case "enter", "y":
+			return m, createInitialCommit(m.cfg.SignCommits)
+		case "esc", "q", "n":
 			m.state = types.StateList
 			m.resetNewModal()
 		}
@@ -248,6 +1499,9 @@ func (m Model) handleNewWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.newTypeListOpen {
 		return m.handleTypeList(msg)
 	}
+	if m.newBranchOpen {
+		return m.handleExistingBranchList(msg)
+	}
 
 	switch msg.Type {
 
@@ -257,20 +1511,70 @@ func (m Model) handleNewWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// Tab and Down both advance to the next field.
 	case tea.KeyTab, tea.KeyDown:
-		m.newActiveField = (m.newActiveField + 1) % 4
+		m.newActiveField = (m.newActiveField + 1) % 5
 
 	case tea.KeyUp:
-		m.newActiveField = (m.newActiveField + 3) % 4 // wraps backward
+		m.newActiveField = (m.newActiveField + 4) % 5 // wraps backward
 
 	case tea.KeyEnter:
 		if m.newActiveField == 0 {
-			// Open the type picker.
+			// Open the type picker on the current choice.
 			m.newTypeListOpen = true
+			m.newTypeCursor = m.newTypeIdx
+			for i := range m.cfg.Templates {
+				if m.newTemplate != nil && m.cfg.Templates[i].Name == m.newTemplate.Name {
+					m.newTypeCursor = len(branchTypes) + i
+				}
+			}
 		} else if m.newDisplayName != "" && m.newBranch != "" {
-			root, _ := git.GetRepoRoot()
-			safePath := strings.ReplaceAll(m.newBranch, "/", "-")
-			wtPath := filepath.Join(root, ".wt", safePath)
-			return m, createWorktree(m.newDisplayName, m.newBranch, wtPath, m.newDescription)
+			// Warn once about a tag clash or the worktree cap and let a
+			// second enter confirm.
+			if m.newWarnedFor != m.newBranch {
+				created := m.newBranch
+				if m.newExisting != "" {
+					created = ""
+				}
+				if w := CreationWarnings(m.cfg, created, len(m.allWorktrees)); len(w) > 0 {
+					m.newWarnings, m.newWarnedFor = w, m.newBranch
+					return m, nil
+				}
+			}
+			wtPath, description, err := m.newWorktreeTarget()
+			if err != nil {
+				m.errMsg = err.Error()
+				return m, nil
+			}
+			if m.newExisting == "" && m.newBase != "" {
+				root, _ := git.GetRepoRoot()
+				if _, err := git.ResolveCommit(root, m.newBase); err != nil {
+					m.errMsg = fmt.Sprintf("base %q is not a tag, branch or commit", m.newBase)
+					return m, nil
+				}
+			}
+			if m.newExisting != "" {
+				return m, attachWorktree(m.newDisplayName, m.newExisting, wtPath, description, m.newTemplate)
+			}
+			return m, createWorktree(m.newDisplayName, m.newBranch, wtPath, description, m.newBase, m.newTemplate, m.cfg.SignCommits)
+		}
+
+	// Undo a manual branch edit: follow type + name again.
+	case tea.KeyCtrlR:
+		m.newBranchEdited = false
+		m.newExisting = ""
+		m.recalcBranch()
+
+	// Attach to a local branch that has no worktree instead of creating one.
+	case tea.KeyCtrlB:
+		return m.openExistingBranchList()
+
+	case tea.KeyCtrlY:
+		if m.newBranch != "" {
+			wtPath, _, err := m.newWorktreeTarget()
+			if err != nil {
+				m.errMsg = err.Error()
+				return m, nil
+			}
+			return m, copyAddCommand(m.newBranch, wtPath, m.newBase, m.newExisting != "", m.newTemplate)
 		}
 
 	case tea.KeySpace:
@@ -286,19 +1590,76 @@ func (m Model) handleNewWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openExistingBranchList opens the picker of local branches that are not
+// checked out in any worktree.
+func (m Model) openExistingBranchList() (tea.Model, tea.Cmd) {
+	refs, err := git.ListBranches()
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	used := make(map[string]bool)
+	for _, wt := range m.allWorktrees {
+		used[wt.Branch] = true
+	}
+	var items []pickerItem
+	for _, r := range refs {
+		if r.Remote == "" && !used[r.Name] {
+			items = append(items, pickerItem{Label: r.Name})
+		}
+	}
+	if len(items) == 0 {
+		return m, m.setStatus("every local branch already has a worktree")
+	}
+	m.newBranchPicker = newPicker(items)
+	m.newBranchOpen = true
+	return m, nil
+}
+
+// handleExistingBranchList picks the branch the new worktree attaches to.
+// The name follows it unless one was typed already.
+func (m Model) handleExistingBranchList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.newBranchOpen = false
+	case tea.KeyEnter:
+		i := m.newBranchPicker.selected()
+		if i < 0 {
+			return m, nil
+		}
+		branch := m.newBranchPicker.items[i].Label
+		m.newBranchOpen = false
+		m.newExisting, m.newBranch, m.newBranchEdited = branch, branch, true
+		m.newBase, m.newBaseFrom = "", ""
+		if m.newDisplayName == "" {
+			m.newDisplayName = branch
+		}
+		m.newActiveField = 1
+	default:
+		m.newBranchPicker = m.newBranchPicker.update(msg)
+	}
+	return m, nil
+}
+
 // handleTypeList handles key input while the type-picker overlay is visible.
 func (m Model) handleTypeList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "up", "k":
-		if m.newTypeIdx > 0 {
-			m.newTypeIdx--
+		if m.newTypeCursor > 0 {
+			m.newTypeCursor--
 		}
 	case "down", "j":
-		if m.newTypeIdx < len(branchTypes)-1 {
-			m.newTypeIdx++
+		if m.newTypeCursor < len(branchTypes)+len(m.cfg.Templates)-1 {
+			m.newTypeCursor++
 		}
 	case "enter":
 		m.newTypeListOpen = false
+		if m.newTypeCursor < len(branchTypes) {
+			m.newTypeIdx = m.newTypeCursor
+			m.newTemplate = nil
+		} else {
+			m.applyTemplate(m.cfg.Templates[m.newTypeCursor-len(branchTypes)])
+		}
 		m.recalcBranch()
 	case "esc":
 		m.newTypeListOpen = false
@@ -309,7 +1670,7 @@ func (m Model) handleTypeList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleRightPaneFocused(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var commits []types.Commit
 	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
-		commits = m.worktrees[m.cursor-1].Commits
+		commits, _ = m.shownCommits(m.worktrees[m.cursor-1])
 	}
 
 	switch msg.String() {
@@ -317,6 +1678,12 @@ func (m Model) handleRightPaneFocused(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case "esc":
 		m.state = types.StateList
+	case "F":
+		return m.startBranchFetch()
+	case "u":
+		m.unmergedOnly = !m.unmergedOnly
+		m.selectedCommitIndex = 0
+		return m, m.maybeLoadUnmerged()
 	case "up", "k":
 		if m.selectedCommitIndex > 0 {
 			m.selectedCommitIndex--
@@ -325,49 +1692,547 @@ func (m Model) handleRightPaneFocused(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.selectedCommitIndex < len(commits)-1 {
 			m.selectedCommitIndex++
 		}
+	case "left", "h", "right", "l":
+		// Jump between columns when the commit list is reflowed.
+		if m.rightPaneInnerW() < wideCommitListW || len(commits) < 2 {
+			return m, nil
+		}
+		split := commitColumnSplit(len(commits))
+		if (msg.String() == "left" || msg.String() == "h") && m.selectedCommitIndex >= split {
+			m.selectedCommitIndex -= split
+		} else if (msg.String() == "right" || msg.String() == "l") && m.selectedCommitIndex < split {
+			m.selectedCommitIndex += split
+			if m.selectedCommitIndex > len(commits)-1 {
+				m.selectedCommitIndex = len(commits) - 1
+			}
+		}
 	case "enter":
 		if len(commits) > 0 && m.selectedCommitIndex < len(commits) {
-			c := commits[m.selectedCommitIndex]
-			wt := m.worktrees[m.cursor-1]
-			// Pre-populate with what we already know; full data arrives async.
-			m.activeCommit = types.CommitDetail{
-				ShortHash: c.Hash,
-				Subject:   c.Message,
-				RelTime:   c.RelTime,
-			}
-			m.commitDetailScroll = 0
-			m.state = types.StateCommitDetail
-			return m, loadCommitDetail(wt.Path, c.Hash)
+			return m.openCommitDetail(m.worktrees[m.cursor-1].Path, commits[m.selectedCommitIndex])
 		}
 	}
 	return m, nil
 }
 
-func (m Model) handleCommitDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// openCommitDetail opens the Level 3 overlay for c; esc returns to the
+// current state.
+func (m Model) openCommitDetail(path string, c types.Commit) (tea.Model, tea.Cmd) {
+	// Pre-populate with what we already know; full data arrives async.
+	m.activeCommit = types.CommitDetail{
+		ShortHash: c.Hash,
+		Subject:   c.Message,
+		RelTime:   c.RelTime,
+	}
+	m.commitDetailScroll = 0
+	m.splitFile, m.splitDiffScroll = 0, 0
+	m.rootDiffShown = false
+	m.activeCommitPath = path
+	m.overlayReturn = m.state
+	m.state = types.StateCommitDetail
+	return m, loadCommitDetail(path, c.Hash, m.diffOptions())
+}
+
+// activityEntry is one row of the activity view: a commit and the
+// worktrees whose recent history contains it.
+type activityEntry struct {
+	commit    types.Commit
+	path      string   // worktree to load the commit from
+	worktrees []string // display names, in list order
+}
+
+// buildActivity merges every worktree's recent commits into one list,
+// newest first. A commit shared by several worktrees appears once.
+func buildActivity(wts []types.Worktree) []activityEntry {
+	var entries []activityEntry
+	seen := make(map[string]int)
+	for _, wt := range wts {
+		for _, c := range wt.Commits {
+			if i, ok := seen[c.Hash]; ok {
+				entries[i].worktrees = append(entries[i].worktrees, wt.Name)
+				continue
+			}
+			seen[c.Hash] = len(entries)
+			entries = append(entries, activityEntry{commit: c, path: wt.Path, worktrees: []string{wt.Name}})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].commit.Time > entries[j].commit.Time
+	})
+	return entries
+}
+
+func (m Model) handleActivity(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "q":
+		return m, tea.Quit
 	case "esc":
-		m.state = types.StateRightPaneFocused
+		m.state = types.StateList
 	case "up", "k":
-		if m.commitDetailScroll > 0 {
-			m.commitDetailScroll--
+		if m.activityCursor > 0 {
+			m.activityCursor--
 		}
 	case "down", "j":
-		m.commitDetailScroll++
+		if m.activityCursor < len(m.activity)-1 {
+			m.activityCursor++
+		}
+	case "enter":
+		if m.activityCursor < len(m.activity) {
+			e := m.activity[m.activityCursor]
+			return m.openCommitDetail(e.path, e.commit)
+		}
 	}
 	return m, nil
 }
 
-// deleteChar removes the last rune from the currently active field.
-func (m *Model) deleteChar() {
-	switch m.newActiveField {
-	case 1:
+// fileTreeDepth is how many directory levels the file tree overlay shows;
+// anything deeper is summed into its ancestor's file count.
+const fileTreeDepth = 2
+
+// treeEntry is one row of the file tree overlay.
+type treeEntry struct {
+	name  string // base name; directories end in "/"
+	depth int    // 0 = top level
+	files int    // directories: files anywhere below
+}
+
+// buildFileTree arranges paths into a tree at most depth levels deep,
+// directories before files and each group sorted by name.
+func buildFileTree(paths []string, depth int) []treeEntry {
+	type node struct {
+		dirs  map[string]*node
+		files []string
+		count int
+	}
+	newNode := func() *node { return &node{dirs: make(map[string]*node)} }
+	root := newNode()
+	for _, p := range paths {
+		parts := strings.Split(p, "/")
+		n := root
+		for i, part := range parts[:len(parts)-1] {
+			if i == depth {
+				break
+			}
+			child, ok := n.dirs[part]
+			if !ok {
+				child = newNode()
+				n.dirs[part] = child
+			}
+			child.count++
+			n = child
+		}
+		if len(parts) <= depth {
+			n.files = append(n.files, parts[len(parts)-1])
+		}
+	}
+	var out []treeEntry
+	var walk func(n *node, d int)
+	walk = func(n *node, d int) {
+		names := make([]string, 0, len(n.dirs))
+		for name := range n.dirs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := n.dirs[name]
+			out = append(out, treeEntry{name: name + "/", depth: d, files: child.count})
+			walk(child, d+1)
+		}
+		sort.Strings(n.files)
+		for _, f := range n.files {
+			out = append(out, treeEntry{name: f, depth: d})
+		}
+	}
+	walk(root, 0)
+	return out
+}
+
+func (m Model) handleFileTree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := max(len(m.fileTree)-m.fileTreeHeight(), 0)
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.fileTreeScroll = max(m.fileTreeScroll-1, 0)
+	case "down", "j":
+		m.fileTreeScroll = min(m.fileTreeScroll+1, maxScroll)
+	case "pgup":
+		m.fileTreeScroll = max(m.fileTreeScroll-m.fileTreeHeight(), 0)
+	case "pgdown":
+		m.fileTreeScroll = min(m.fileTreeScroll+m.fileTreeHeight(), maxScroll)
+	case "G", "end":
+		m.fileTreeScroll = maxScroll
+	case "g", "home":
+		m.fileTreeScroll = 0
+	case "esc", "f":
+		m.fileTree, m.fileTreePath = nil, ""
+		m.state = types.StateList
+	}
+	return m, nil
+}
+
+// branchAge is one row of the branch ages view.
+type branchAge struct {
+	name   string
+	start  time.Time
+	age    string // e.g. "3d", as of loading
+	unique bool   // start is the branch's first own commit, not its fork point
+}
+
+func (m Model) handleBranchAges(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "esc", "S":
+		m.state = types.StateList
+	case "up", "k":
+		if m.branchAgesScroll > 0 {
+			m.branchAgesScroll--
+		}
+	case "down", "j":
+		if m.branchAgesScroll < len(m.branchAges)-1 {
+			m.branchAgesScroll++
+		}
+	}
+	return m, nil
+}
+
+// mergedClean returns the worktrees recommend calls merged that have no
+// uncommitted or untracked files, skipping pinned, locked and protected ones
+// and the one the TUI was started from.
+func (m Model) mergedClean() []types.Worktree {
+	cur, _ := git.GetRepoRoot()
+	var out []types.Worktree
+	for _, wt := range m.allWorktrees {
+		r, ok := m.recommend(wt)
+		if !ok || (r.short != "merged" && r.short != "done") || wt.StatusChanged+wt.StatusUntracked > 0 ||
+			wt.Locked || m.isPinned(wt.Branch) || m.protectedCache[wt.Branch] || wt.Path == cur {
+			continue
+		}
+		out = append(out, wt)
+	}
+	return out
+}
+
+// finished returns the worktrees recommend calls done — merged, upstream
+// gone and clean — skipping pinned, locked and protected ones and the one
+// the TUI was started from.
+func (m Model) finished() []types.Worktree {
+	var out []types.Worktree
+	for _, wt := range m.mergedClean() {
+		if r, _ := m.recommend(wt); r.short == "done" {
+			out = append(out, wt)
+		}
+	}
+	return out
+}
+
+// handlePruneMerged asks once before deleting; after the deletions any key
+// dismisses the results.
+func (m Model) handlePruneMerged(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pruneResults != nil {
+		m.state = types.StateList
+		m.pruneCandidates, m.pruneResults, m.pruneFinished = nil, nil, false
+		return m, nil
+	}
+	switch msg.String() {
+	case "y":
+		return m, pruneWorktrees(m.pruneCandidates)
+	case "n", "esc":
+		m.state = types.StateList
+		m.pruneCandidates, m.pruneFinished = nil, false
+	}
+	return m, nil
+}
+
+func (m Model) handleGitLink(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "esc", "i":
+		m.state = types.StateList
+	}
+	return m, nil
+}
+
+// handleHookOutput scrolls and closes the hook output overlay.
+func (m Model) handleHookOutput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := max(len(strings.Split(m.hookOut, "\n"))-m.hookOutputHeight(), 0)
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.hookScroll = max(m.hookScroll-1, 0)
+	case "down", "j":
+		m.hookScroll = min(m.hookScroll+1, maxScroll)
+	case "G", "end":
+		m.hookScroll = maxScroll
+	case "g", "home":
+		m.hookScroll = 0
+	case "esc", "enter":
+		m.hookOut, m.hookErr, m.hookPath = "", "", ""
+		m.state = types.StateList
+		return m, m.maybeFetchPR()
+	}
+	return m, nil
+}
+
+// reloadConfig re-reads the config file and applies it to the running UI.
+// On any problem the current config stays, and the error is shown.
+func (m Model) reloadConfig() (tea.Model, tea.Cmd) {
+	cfg, err := config.Load()
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	// A --read-only session stays read-only.
+	cfg.ReadOnly = cfg.ReadOnly || m.cfg.ReadOnly
+	cfg.NoShellPrompt = cfg.NoShellPrompt || m.cfg.NoShellPrompt
+	applyBorder(cfg.BorderStyle)
+	if cfg.DiffAlgorithm != m.cfg.DiffAlgorithm {
+		m.diffAlgorithm = cfg.DiffAlgorithm
+	}
+	m.cfg = cfg
+	m.relist()
+	return m, tea.Batch(m.setStatus("config reloaded"), m.maybeFetchPR(), m.prefetchPRs())
+}
+
+// startBranchFetch fetches only the selected worktree's branch.
+func (m Model) startBranchFetch() (tea.Model, tea.Cmd) {
+	if m.cursor == 0 || m.fetchingBranch != "" {
+		return m, nil
+	}
+	wt := m.worktrees[m.cursor-1]
+	if wt.Branch == "(detached)" || wt.Branch == "(bare)" {
+		return m, nil
+	}
+	m.fetchingBranch = wt.Branch
+	return m, fetchBranch(wt.Path, wt.Branch)
+}
+
+// startPush pushes the selected worktree's branch to origin with -u.
+func (m Model) startPush() (tea.Model, tea.Cmd) {
+	if m.cursor == 0 || m.pushingBranch != "" {
+		return m, nil
+	}
+	wt := m.worktrees[m.cursor-1]
+	if wt.Unborn || wt.Branch == "(detached)" || wt.Branch == "(bare)" {
+		return m, nil
+	}
+	m.pushingBranch = wt.Branch
+	return m, tea.Batch(m.setStatus("pushing "+wt.Branch+"…"), pushBranch(wt.Path, wt.Branch))
+}
+
+// handleCommitDetail drives the diff overlay for both a single commit and
+// the working-tree diff; esc returns to wherever the overlay was opened from.
+func (m Model) handleCommitDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.commitSplit && m.state == types.StateCommitDetail && m.handleCommitSplit(msg) {
+		return m, nil
+	}
+	switch msg.String() {
+	case "s":
+		if m.state == types.StateCommitDetail {
+			m.commitSplit = !m.commitSplit
+		}
+	case "d":
+		if m.activeCommit.Root {
+			m.rootDiffShown = !m.rootDiffShown
+			m.commitDetailScroll = min(m.commitDetailScroll, m.commitDetailMaxScroll())
+		}
+	case "esc":
+		m.state = m.overlayReturn
+	case "up", "k":
+		m.scrollCommitDetail(-1)
+	case "down", "j":
+		m.scrollCommitDetail(1)
+	case "pgup":
+		_, page := m.commitDetailSize()
+		m.scrollCommitDetail(-page)
+	case "pgdown", " ":
+		_, page := m.commitDetailSize()
+		m.scrollCommitDetail(page)
+	case "ctrl+u":
+		_, page := m.commitDetailSize()
+		m.scrollCommitDetail(-max(page/2, 1))
+	case "ctrl+d":
+		_, page := m.commitDetailSize()
+		m.scrollCommitDetail(max(page/2, 1))
+	case "g", "home":
+		m.commitDetailScroll = 0
+	case "G", "end":
+		m.commitDetailScroll = m.commitDetailMaxScroll()
+	case "tab", "shift+tab":
+		n := len(m.activeCommit.Files)
+		step := 1
+		if msg.String() == "shift+tab" {
+			step = n - 1
+		}
+		if n > 0 && m.state == types.StateWorkingDiff {
+			m.workingDiffFile = (m.workingDiffFile + step) % n
+		} else if n > 0 {
+			m.splitFile = (m.splitFile + step) % n
+		}
+	case "o", "O":
+		if m.state != types.StateCommitDetail || !m.activeCommit.Loaded || m.splitFile >= len(m.activeCommit.Files) {
+			return m, nil
+		}
+		f := m.activeCommit.Files[m.splitFile]
+		if msg.String() == "o" {
+			return m, snapshotFileAtRev(m.activeCommitPath, m.activeCommit.ShortHash, f)
+		}
+		path := filepath.Join(m.activeCommitPath, filepath.FromSlash(f.Path))
+		if _, err := os.Stat(path); err != nil {
+			m.errMsg = f.Path + " is not in the working tree"
+			return m, nil
+		}
+		return m, m.openFile(path, m.activeCommitPath)
+	case "c":
+		if m.state != types.StateWorkingDiff || !m.activeCommit.Loaded || len(m.activeCommit.Files) == 0 {
+			return m, nil
+		}
+		if m.cfg.ReadOnly {
+			m.errMsg = readOnlyNotice
+			return m, nil
+		}
+		m.commitSubject, m.commitBody, m.commitErr = "", "", ""
+		m.commitActiveField = 0
+		// Default to -a when nothing is staged yet.
+		m.commitAll = !anyStaged(m.activeCommit.Files)
+		m.state = types.StateCommitMessage
+	case "a", "u":
+		if m.state == types.StateWorkingDiff {
+			return m.stageSelectedFile(msg.String() == "u")
+		}
+		if msg.String() == "u" {
+			return m, nil
+		}
+		// Cycle diff algorithms and re-fetch the patch.
+		m.diffAlgorithm = nextDiffAlgorithm(m.diffAlgorithm)
+		m.activeCommit.Loaded = false
+		return m, m.reloadActiveDiff()
+	case "y":
+		if m.state == types.StateCommitDetail && m.activeCommit.ShortHash != "" {
+			return m, copyCommitLink(m.activeCommitPath, m.activeCommit.ShortHash)
+		}
+	case "Y":
+		if files := m.activeCommit.Files; len(files) > 0 {
+			return m, copyFileList(files)
+		}
+	case "W":
+		m.diffIgnoreWS = !m.diffIgnoreWS
+		m.activeCommit.Loaded = false
+		return m, m.reloadActiveDiff()
+	}
+	return m, nil
+}
+
+// stageSelectedFile stages or unstages the file under the working-diff
+// cursor.
+func (m Model) stageSelectedFile(unstage bool) (tea.Model, tea.Cmd) {
+	files := m.activeCommit.Files
+	if !m.activeCommit.Loaded || m.workingDiffFile >= len(files) {
+		return m, nil
+	}
+	if m.cfg.ReadOnly {
+		m.errMsg = readOnlyNotice
+		return m, nil
+	}
+	f := files[m.workingDiffFile]
+	if (unstage && !f.Staged) || (!unstage && !f.Unstaged) {
+		return m, nil // nothing to move
+	}
+	return m, stageFile(m.activeCommitPath, f.Path, unstage)
+}
+
+// handleCommitSplit handles navigation in the split commit layout: ↑↓ and
+// tab pick the file, the paging keys scroll its diff. It reports whether it
+// used the key.
+func (m *Model) handleCommitSplit(msg tea.KeyMsg) bool {
+	n := len(m.activeCommit.Files)
+	_, _, diffH := m.commitSplitSize()
+	selectFile := func(i int) {
+		if n > 0 {
+			m.splitFile = (i + n) % n
+			m.splitDiffScroll = 0
+		}
+	}
+	switch msg.String() {
+	case "up", "k", "shift+tab":
+		selectFile(m.splitFile - 1)
+	case "down", "j", "tab":
+		selectFile(m.splitFile + 1)
+	case "pgup":
+		m.scrollSplitDiff(-diffH)
+	case "pgdown", " ":
+		m.scrollSplitDiff(diffH)
+	case "ctrl+u":
+		m.scrollSplitDiff(-max(diffH/2, 1))
+	case "ctrl+d":
+		m.scrollSplitDiff(max(diffH/2, 1))
+	case "g", "home":
+		m.splitDiffScroll = 0
+	case "G", "end":
+		m.scrollSplitDiff(len(m.splitDiff()))
+	default:
+		return false
+	}
+	return true
+}
+
+// scrollSplitDiff moves the split layout's diff by delta lines, clamped.
+func (m *Model) scrollSplitDiff(delta int) {
+	_, _, diffH := m.commitSplitSize()
+	maxScroll := max(len(m.splitDiff())-diffH, 0)
+	m.splitDiffScroll = min(max(m.splitDiffScroll+delta, 0), maxScroll)
+}
+
+// scrollCommitDetail moves the overlay by delta lines, clamped to the content.
+func (m *Model) scrollCommitDetail(delta int) {
+	m.commitDetailScroll = min(max(m.commitDetailScroll+delta, 0), m.commitDetailMaxScroll())
+}
+
+// reloadActiveDiff re-fetches whatever the diff overlay is showing.
+func (m Model) reloadActiveDiff() tea.Cmd {
+	if m.state == types.StateWorkingDiff {
+		return loadWorkingDiff(m.activeCommitPath, m.diffOptions())
+	}
+	if m.overlayReturn == types.StateStash && m.stashCursor < len(m.stashes) {
+		return loadStashDetail(m.activeCommitPath, m.stashes[m.stashCursor].Ref, m.diffOptions())
+	}
+	return loadCommitDetail(m.activeCommitPath, m.activeCommit.ShortHash, m.diffOptions())
+}
+
+// diffOptions returns the patch options for the commit overlay.
+func (m Model) diffOptions() git.DiffOptions {
+	return git.DiffOptions{Algorithm: m.diffAlgorithm, IgnoreWhitespace: m.diffIgnoreWS}
+}
+
+// nextDiffAlgorithm returns the algorithm after cur in config.DiffAlgorithms.
+func nextDiffAlgorithm(cur string) string {
+	for i, a := range config.DiffAlgorithms {
+		if a == cur {
+			return config.DiffAlgorithms[(i+1)%len(config.DiffAlgorithms)]
+		}
+	}
+	return config.DiffAlgorithms[0]
+}
+
+// deleteChar removes the last rune from the currently active field.
+func (m *Model) deleteChar() {
+	switch m.newActiveField {
+	case 1:
 		m.newDisplayName = dropLast(m.newDisplayName)
 		m.recalcBranch()
 	case 2:
 		m.newBranch = dropLast(m.newBranch)
 		m.newBranchEdited = true
+		m.newExisting = ""
 	case 3:
 		m.newDescription = dropLast(m.newDescription)
+	case 4:
+		if m.newExisting == "" {
+			m.newBase = dropLast(m.newBase)
+			m.newBaseFrom = ""
+		}
 	}
 	// Field 0 (type) ignores backspace — use the type picker instead.
 }
@@ -390,8 +2255,33 @@ func (m *Model) appendRunes(runes []rune) {
 			m.newBranch += string(r)
 		}
 		m.newBranchEdited = true
+		m.newExisting = ""
 	case 3: // Description — full free text
 		m.newDescription += string(runes)
+	case 4: // Base — a ref, so no spaces; unused when attaching to a branch
+		if m.newExisting != "" {
+			return
+		}
+		for _, r := range runes {
+			if !unicode.IsSpace(r) {
+				m.newBase += string(r)
+			}
+		}
+		m.newBaseFrom = ""
+	}
+}
+
+// applyTemplate pre-fills the new-worktree form from t. A type that is not
+// one of branchTypes leaves the current type alone.
+func (m *Model) applyTemplate(t config.Template) {
+	m.newTemplate = &t
+	for i, bt := range branchTypes {
+		if bt == t.Type {
+			m.newTypeIdx = i
+		}
+	}
+	if t.Description != "" {
+		m.newDescription = t.Description
 	}
 }
 
@@ -401,7 +2291,7 @@ func (m *Model) recalcBranch() {
 	if m.newBranchEdited {
 		return
 	}
-	slug := slugify(m.newDisplayName)
+	slug := slugify(m.newDisplayName, m.cfg.Slug)
 	if slug == "" {
 		m.newBranch = branchTypes[m.newTypeIdx]
 	} else {
@@ -413,21 +2303,732 @@ func (m Model) handleEditWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
 	case tea.KeyEsc:
 		m.state = types.StateList
-		m.editName = ""
+		m.resetEditModal()
+	case tea.KeyTab, tea.KeyDown:
+		m.editActiveField = (m.editActiveField + 1) % 4
+	case tea.KeyUp:
+		m.editActiveField = (m.editActiveField + 3) % 4 // wraps backward
+	case tea.KeyLeft, tea.KeyRight:
+		if m.editActiveField == 0 {
+			step := 1
+			if msg.Type == tea.KeyLeft {
+				step = len(branchTypes) - 1
+			}
+			if m.editTypeIdx < 0 {
+				m.editTypeIdx = 0
+			} else {
+				m.editTypeIdx = (m.editTypeIdx + step) % len(branchTypes)
+			}
+			m.editBranch = retypeBranch(m.editBranch, branchTypes[m.editTypeIdx], m.editDisplayName, m.cfg.Slug)
+		}
+	case tea.KeyEnter:
+		if m.cursor > 0 && m.editBranch != "" {
+			wt := m.worktrees[m.cursor-1]
+			metaChanged := m.editDisplayName != wt.Name || m.editDescription != wt.Description
+			if wt.Branch != m.editBranch || metaChanged {
+				return m, saveWorktreeEdit(wt.Branch, m.editBranch, wt.Path, m.editDisplayName, m.editDescription, metaChanged)
+			}
+		}
+		m.state = types.StateList
+		m.resetEditModal()
+	case tea.KeyBackspace:
+		switch m.editActiveField {
+		case 1:
+			m.editDisplayName = dropLast(m.editDisplayName)
+		case 2:
+			m.editBranch = dropLast(m.editBranch)
+		case 3:
+			m.editDescription = dropLast(m.editDescription)
+		}
+	case tea.KeySpace:
+		m.appendEditRunes([]rune{' '})
+	case tea.KeyRunes:
+		m.appendEditRunes(msg.Runes)
+	}
+	return m, nil
+}
+
+// appendEditRunes adds typed characters to the active edit field. As in the
+// create form, spaces in the branch field become hyphens.
+func (m *Model) appendEditRunes(runes []rune) {
+	switch m.editActiveField {
+	case 0:
+		// Type is cycled with ←/→, not typed.
+	case 1:
+		m.editDisplayName += string(runes)
+	case 2:
+		for _, r := range runes {
+			if unicode.IsSpace(r) {
+				r = '-'
+			}
+			m.editBranch += string(r)
+		}
+	case 3:
+		m.editDescription += string(runes)
+	}
+}
+
+// branchTypeIndex returns the index in branchTypes of branch's type prefix,
+// or -1 if it has none.
+func branchTypeIndex(branch string) int {
+	prefix, _, ok := strings.Cut(branch, "/")
+	if !ok {
+		return -1
+	}
+	for i, t := range branchTypes {
+		if t == prefix {
+			return i
+		}
+	}
+	return -1
+}
+
+// retypeBranch swaps branch's type prefix for typ, keeping the rest. A branch
+// without a known prefix is rebuilt from typ and name as the create form
+// would, with slug options opts.
+func retypeBranch(branch, typ, name string, opts config.SlugOptions) string {
+	if branchTypeIndex(branch) >= 0 {
+		_, rest, _ := strings.Cut(branch, "/")
+		return typ + "/" + rest
+	}
+	if slug := slugify(name, opts); slug != "" {
+		return typ + "/" + slug
+	}
+	return typ + "/" + branch
+}
+
+// resetEditModal zeroes all edit modal state.
+func (m *Model) resetEditModal() {
+	m.editDisplayName = ""
+	m.editBranch = ""
+	m.editDescription = ""
+	m.editTypeIdx = -1
+	m.editActiveField = 1
+}
+
+func (m Model) handleMoveWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+		m.moveDest = ""
+		m.moveErr = ""
 	case tea.KeyEnter:
-		if m.cursor > 0 && m.editName != "" {
+		if m.cursor == 0 || m.moveDest == "" {
+			return m, nil
+		}
+		wt := m.worktrees[m.cursor-1]
+		root, _ := git.GetRepoRoot()
+		dest := git.ExpandPath(m.moveDest, root)
+		if dest == wt.Path {
+			m.state = types.StateList
+			return m, nil
+		}
+		if err := git.ValidateMoveTarget(dest); err != nil {
+			m.moveErr = err.Error()
+			return m, nil
+		}
+		return m, moveWorktree(wt.Path, dest)
+	case tea.KeyBackspace:
+		m.moveDest = dropLast(m.moveDest)
+		m.moveErr = ""
+	case tea.KeySpace:
+		m.moveDest += " "
+		m.moveErr = ""
+	case tea.KeyRunes:
+		m.moveDest += string(msg.Runes)
+		m.moveErr = ""
+	}
+	return m, nil
+}
+
+// handleNotes edits the multi-line notes draft. Enter inserts a newline;
+// ctrl+s saves.
+func (m Model) handleNotes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+		m.notesDraft = ""
+	case tea.KeyCtrlS:
+		if m.cursor > 0 {
 			wt := m.worktrees[m.cursor-1]
-			if wt.Branch != m.editName {
-				return m, renameWorktree(wt.Branch, m.editName)
+			notes := strings.TrimRight(m.notesDraft, "\n ")
+			m.notesDraft = ""
+			if notes != wt.Notes {
+				return m, saveNotes(wt.Branch, notes)
 			}
 		}
 		m.state = types.StateList
+	case tea.KeyEnter:
+		m.notesDraft += "\n"
 	case tea.KeyBackspace:
-		m.editName = dropLast(m.editName)
+		m.notesDraft = dropLast(m.notesDraft)
 	case tea.KeySpace:
-		m.editName += " "
+		m.notesDraft += " "
 	case tea.KeyRunes:
-		m.editName += string(msg.Runes)
+		m.notesDraft += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// handleLabels edits the comma-separated labels draft; enter saves.
+func (m Model) handleLabels(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+		m.labelsDraft = ""
+	case tea.KeyEnter:
+		m.state = types.StateList
+		if m.cursor > 0 {
+			wt := m.worktrees[m.cursor-1]
+			labels := parseLabels(m.labelsDraft)
+			m.labelsDraft = ""
+			if strings.Join(labels, ",") != strings.Join(wt.Labels, ",") {
+				return m, saveLabels(wt.Branch, labels)
+			}
+		}
+	case tea.KeyBackspace:
+		m.labelsDraft = dropLast(m.labelsDraft)
+	case tea.KeySpace:
+		m.labelsDraft += " "
+	case tea.KeyRunes:
+		m.labelsDraft += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// parseLabels splits a comma-separated draft into trimmed, de-duplicated
+// labels, keeping their order.
+func parseLabels(draft string) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, l := range strings.Split(draft, ",") {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		labels = append(labels, l)
+	}
+	return labels
+}
+
+// openLabelFilter lists every label in use, with a first entry that clears
+// the filter.
+func (m Model) openLabelFilter() (tea.Model, tea.Cmd) {
+	counts := make(map[string]int)
+	for _, wt := range m.allWorktrees {
+		for _, l := range wt.Labels {
+			counts[l]++
+		}
+	}
+	labels := make([]string, 0, len(counts))
+	for l := range counts {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	items := []pickerItem{{Label: "all", Detail: "clear the label filter"}}
+	m.labelOptions = []string{""}
+	for _, l := range labels {
+		detail := fmt.Sprintf("%d worktrees", counts[l])
+		if counts[l] == 1 {
+			detail = "1 worktree"
+		}
+		items = append(items, pickerItem{Label: l, Detail: detail})
+		m.labelOptions = append(m.labelOptions, l)
+	}
+	m.labelPicker = newPicker(items)
+	m.state = types.StateLabelFilter
+	return m, nil
+}
+
+func (m Model) handleLabelFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+	case tea.KeyEnter:
+		i := m.labelPicker.selected()
+		if i < 0 {
+			return m, nil
+		}
+		m.labelFilter = m.labelOptions[i]
+		m.state = types.StateList
+		m.relist()
+		return m, m.maybeFetchPR()
+	default:
+		m.labelPicker = m.labelPicker.update(msg)
+	}
+	return m, nil
+}
+
+// anyStaged reports whether any working-diff file has changes in the index.
+func anyStaged(files []types.CommitFile) bool {
+	for _, f := range files {
+		if f.Staged {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) handleCommitMessage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.commitErr = ""
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateWorkingDiff
+	case tea.KeyTab, tea.KeyShiftTab:
+		m.commitActiveField = 1 - m.commitActiveField
+	case tea.KeyCtrlA:
+		m.commitAll = !m.commitAll
+	case tea.KeyEnter, tea.KeyCtrlS:
+		if msg.Type == tea.KeyEnter && m.commitActiveField == 1 {
+			m.commitBody += "\n"
+			return m, nil
+		}
+		subject := strings.TrimSpace(m.commitSubject)
+		if subject == "" {
+			m.commitErr = "subject is required"
+			return m, nil
+		}
+		if !m.commitAll && !anyStaged(m.activeCommit.Files) {
+			m.commitErr = "nothing staged — ctrl+a to commit all tracked changes"
+			return m, nil
+		}
+		message := subject
+		if body := strings.TrimSpace(m.commitBody); body != "" {
+			message += "\n\n" + body
+		}
+		return m, commitChanges(m.activeCommitPath, message, m.commitAll)
+	case tea.KeyBackspace:
+		if m.commitActiveField == 0 {
+			m.commitSubject = dropLast(m.commitSubject)
+		} else {
+			m.commitBody = dropLast(m.commitBody)
+		}
+	case tea.KeySpace, tea.KeyRunes:
+		text := " "
+		if msg.Type == tea.KeyRunes {
+			text = string(msg.Runes)
+		}
+		if m.commitActiveField == 0 {
+			m.commitSubject += text
+		} else {
+			m.commitBody += text
+		}
+	}
+	return m, nil
+}
+
+// openCreatePR opens the Create PR modal for the selected worktree, with the
+// title seeded from its latest commit. It needs gh, and refuses a branch
+// that already has an open PR.
+func (m Model) openCreatePR() (tea.Model, tea.Cmd) {
+	if !m.ghAvailable || m.cursor == 0 {
+		return m, nil
+	}
+	wt := m.worktrees[m.cursor-1]
+	if wt.IsMain || wt.Unborn || wt.Branch == "(detached)" || wt.Branch == "(bare)" {
+		return m, nil
+	}
+	if info := m.prCache[wt.Branch]; info != nil && info.State == "OPEN" {
+		return m, m.setStatus(fmt.Sprintf("%s already has PR #%d", wt.Branch, info.Number))
+	}
+	m.prTitle, m.prBody, m.prDraft, m.prActiveField, m.prErr = "", "", false, 0, ""
+	if len(wt.Commits) > 0 {
+		m.prTitle = wt.Commits[0].Message
+	}
+	m.prBranch, m.prPath = wt.Branch, wt.Path
+	m.state = types.StateCreatePR
+	return m, nil
+}
+
+// handleCreatePR edits the Create PR modal; ctrl+s (or enter on the title)
+// runs gh pr create.
+func (m Model) handleCreatePR(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.prCreating {
+		return m, nil
+	}
+	m.prErr = ""
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+	case tea.KeyTab, tea.KeyShiftTab:
+		m.prActiveField = 1 - m.prActiveField
+	case tea.KeyCtrlD:
+		m.prDraft = !m.prDraft
+	case tea.KeyEnter, tea.KeyCtrlS:
+		if msg.Type == tea.KeyEnter && m.prActiveField == 1 {
+			m.prBody += "\n"
+			return m, nil
+		}
+		title := strings.TrimSpace(m.prTitle)
+		if title == "" {
+			m.prErr = "title is required"
+			return m, nil
+		}
+		m.prCreating = true
+		return m, createPR(m.prPath, m.prBranch, title, strings.TrimSpace(m.prBody), m.prDraft)
+	case tea.KeyBackspace:
+		if m.prActiveField == 0 {
+			m.prTitle = dropLast(m.prTitle)
+		} else {
+			m.prBody = dropLast(m.prBody)
+		}
+	case tea.KeySpace, tea.KeyRunes:
+		text := " "
+		if msg.Type == tea.KeyRunes {
+			text = string(msg.Runes)
+		}
+		if m.prActiveField == 0 {
+			m.prTitle += text
+		} else {
+			m.prBody += text
+		}
+	}
+	return m, nil
+}
+
+// openRepoSwitch loads the repo registry into the switch overlay.
+func (m Model) openRepoSwitch() (tea.Model, tea.Cmd) {
+	repos, err := config.LoadRepos()
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	current, _ := git.GetMainWorktreePath()
+	var items []pickerItem
+	m.repoPaths = nil
+	for _, r := range repos {
+		detail := r
+		if r == current {
+			detail = "current · " + r
+		}
+		items = append(items, pickerItem{Label: filepath.Base(r), Detail: detail})
+		m.repoPaths = append(m.repoPaths, r)
+	}
+	m.repoPicker = newPicker(items)
+	m.state = types.StateRepoSwitch
+	return m, nil
+}
+
+func (m Model) handleRepoSwitch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+	case tea.KeyEnter:
+		i := m.repoPicker.selected()
+		if i < 0 {
+			return m, nil
+		}
+		current, _ := git.GetMainWorktreePath()
+		if m.repoPaths[i] == current {
+			m.state = types.StateList
+			return m, nil
+		}
+		return m, switchRepo(m.repoPaths[i])
+	default:
+		m.repoPicker = m.repoPicker.update(msg)
+	}
+	return m, nil
+}
+
+// branchTarget is what selecting a branch in the switch overlay resolves to.
+type branchTarget struct {
+	ref      git.BranchRef
+	worktree int    // index into m.worktrees when the branch is checked out, else -1
+	path     string // where a new worktree would be created
+}
+
+// resolveBranchTarget decides what selecting ref means: jump to the
+// worktree that already has it checked out, or create one (tracking the
+// remote branch when ref is remote-only). A remote ref whose local branch
+// exists resolves to that local branch.
+func (m Model) resolveBranchTarget(ref git.BranchRef) branchTarget {
+	if ref.Remote != "" {
+		for _, r := range m.branchRefs {
+			if r.Remote == "" && r.Name == ref.Name {
+				ref = r
+				break
+			}
+		}
+	}
+	t := branchTarget{ref: ref, worktree: -1}
+	if ref.Remote == "" {
+		for i, wt := range m.worktrees {
+			if wt.Branch == ref.Name {
+				t.worktree = i
+				break
+			}
+		}
+	}
+	return t
+}
+
+// openBranchSwitch loads local and remote branches into the switch overlay.
+// Remote branches that already have a local counterpart are omitted.
+func (m Model) openBranchSwitch() (tea.Model, tea.Cmd) {
+	refs, err := git.ListBranches()
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	local := make(map[string]bool)
+	for _, r := range refs {
+		if r.Remote == "" {
+			local[r.Name] = true
+		}
+	}
+	m.branchRefs = nil
+	var items []pickerItem
+	for _, r := range refs {
+		if r.Remote != "" && local[r.Name] {
+			continue
+		}
+		m.branchRefs = append(m.branchRefs, r)
+		detail := "no worktree"
+		if r.Remote != "" {
+			detail = "remote"
+		} else if t := m.resolveBranchTarget(r); t.worktree >= 0 {
+			detail = "→ " + m.worktrees[t.worktree].Name
+		}
+		items = append(items, pickerItem{Label: r.Ref(), Detail: detail})
+	}
+	m.branchPicker = newPicker(items)
+	m.branchTarget = nil
+	m.state = types.StateBranchSwitch
+	return m, nil
+}
+
+func (m Model) handleBranchSwitch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Pending create confirmation.
+	if t := m.branchTarget; t != nil {
+		switch msg.String() {
+		case "y", "enter":
+			if m.cfg.ReadOnly {
+				m.branchTarget = nil
+				m.state = types.StateList
+				m.errMsg = readOnlyNotice
+				return m, nil
+			}
+			return m, createWorktreeForTarget(*t)
+		case "n", "esc":
+			m.branchTarget = nil
+		}
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+	case tea.KeyEnter:
+		i := m.branchPicker.selected()
+		if i < 0 {
+			return m, nil
+		}
+		t := m.resolveBranchTarget(m.branchRefs[i])
+		if t.worktree >= 0 {
+			m.state = types.StateList
+			m.cursor = t.worktree + 1
+			return m, m.maybeFetchPR()
+		}
+		root, _ := git.GetRepoRoot()
+		t.path = WorktreePath(root, t.ref.Name)
+		m.branchTarget = &t
+		// Only a remote branch gets a new local branch that could clash.
+		created := ""
+		if t.ref.Remote != "" {
+			created = t.ref.Name
+		}
+		m.branchWarnings = CreationWarnings(m.cfg, created, len(m.allWorktrees))
+	default:
+		m.branchPicker = m.branchPicker.update(msg)
+	}
+	return m, nil
+}
+
+// openMergeConfirm starts the pre-flight check for merging wt's branch into
+// the default branch, which must be checked out in a clean worktree.
+func (m Model) openMergeConfirm(wt types.Worktree) (tea.Model, tea.Cmd) {
+	def := m.defaultBranch
+	if wt.IsMain || wt.Unborn || wt.Missing || def == "" || wt.Branch == def || strings.HasPrefix(wt.Branch, "(") {
+		return m, nil
+	}
+	var target *types.Worktree
+	for i := range m.allWorktrees {
+		if m.allWorktrees[i].Branch == def {
+			target = &m.allWorktrees[i]
+			break
+		}
+	}
+	switch {
+	case target == nil:
+		m.errMsg = def + " is not checked out in any worktree"
+		return m, nil
+	case target.InProgressOp != "":
+		m.errMsg = fmt.Sprintf("%s has a %s in progress", target.Name, target.InProgressOp)
+		return m, nil
+	case target.StatusChanged > 0:
+		m.errMsg = target.Name + " has uncommitted changes — commit or stash them first"
+		return m, nil
+	}
+	m.mergeSource, m.mergeTarget, m.mergePlan = wt, *target, nil
+	m.state = types.StateMergeConfirm
+	return m, planMerge(target.Path, wt.Branch, def)
+}
+
+// handleMergeConfirm merges on y once the pre-flight check has come back
+// with something to merge and no conflicts.
+func (m Model) handleMergeConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		if p := m.mergePlan; p != nil && p.err == nil && p.ahead > 0 && len(p.conflicts) == 0 {
+			return m, mergeBranch(m.mergeTarget.Path, m.mergeSource.Branch)
+		}
+	case "n", "esc":
+		m.state = types.StateList
+	}
+	return m, nil
+}
+
+// openStashes opens the stash overlay. Apply and pop target the selected
+// worktree, or the one the TUI was started in when none is.
+func (m Model) openStashes() (tea.Model, tea.Cmd) {
+	if m.cursor > 0 {
+		wt := m.worktrees[m.cursor-1]
+		if wt.Missing {
+			return m, nil
+		}
+		m.stashPath, m.stashName = wt.Path, wt.Name
+	} else {
+		root, err := git.GetRepoRoot()
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		m.stashPath, m.stashName = root, filepath.Base(root)
+	}
+	m.stashes, m.stashCursor, m.stashDropArmed = nil, 0, false
+	m.state = types.StateStash
+	return m, loadStashes
+}
+
+func (m Model) handleStash(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	armed := m.stashDropArmed
+	m.stashDropArmed = false
+	key := msg.String()
+	switch key {
+	case "q":
+		return m, tea.Quit
+	case "esc":
+		m.state = types.StateList
+	case "up", "k":
+		if m.stashCursor > 0 {
+			m.stashCursor--
+		}
+	case "down", "j":
+		if m.stashCursor < len(m.stashes)-1 {
+			m.stashCursor++
+		}
+	case "enter", "v":
+		if m.stashCursor < len(m.stashes) {
+			e := m.stashes[m.stashCursor]
+			m.activeCommit = types.CommitDetail{ShortHash: e.Hash, Subject: e.Subject, RelTime: e.RelTime}
+			m.commitDetailScroll = 0
+			m.splitFile, m.splitDiffScroll = 0, 0
+			m.rootDiffShown = false
+			m.activeCommitPath = m.stashPath
+			m.overlayReturn = m.state
+			m.state = types.StateCommitDetail
+			return m, loadStashDetail(m.stashPath, e.Ref, m.diffOptions())
+		}
+	case "a", "p", "d":
+		if m.stashCursor >= len(m.stashes) {
+			return m, nil
+		}
+		if m.cfg.ReadOnly {
+			m.errMsg = readOnlyNotice
+			return m, nil
+		}
+		ref := m.stashes[m.stashCursor].Ref
+		switch {
+		case key == "a":
+			return m, applyStash(m.stashPath, ref, "applied")
+		case key == "p":
+			return m, applyStash(m.stashPath, ref, "popped")
+		case armed:
+			return m, applyStash(m.stashPath, ref, "dropped")
+		default:
+			m.stashDropArmed = true
+		}
+	}
+	return m, nil
+}
+
+// handlePRCheckout picks an open PR: one already checked out jumps to its
+// worktree, any other gets a new worktree at the default location.
+func (m Model) handlePRCheckout(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = types.StateList
+	case tea.KeyEnter:
+		i := m.prPicker.selected()
+		if i < 0 {
+			return m, nil
+		}
+		pr := m.prList[i]
+		for j, wt := range m.worktrees {
+			if wt.Branch == pr.LocalBranch() {
+				m.state = types.StateList
+				m.cursor = j + 1
+				return m, m.maybeFetchPR()
+			}
+		}
+		if m.prWarnedFor != pr.Number {
+			if w := CreationWarnings(m.cfg, pr.LocalBranch(), len(m.allWorktrees)); len(w) > 0 {
+				m.prWarnings, m.prWarnedFor = w, pr.Number
+				return m, nil
+			}
+		}
+		root, err := git.GetRepoRoot()
+		if err != nil {
+			m.state = types.StateList
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		return m, tea.Batch(m.setStatus(fmt.Sprintf("checking out #%d…", pr.Number)),
+			checkoutPR(pr, WorktreePath(root, pr.LocalBranch())))
+	default:
+		m.prPicker = m.prPicker.update(msg)
+	}
+	return m, nil
+}
+
+// cdTo leaves the path of wt for the shell wrapper to cd to, and quits.
+func (m Model) cdTo(wt types.Worktree) (tea.Model, tea.Cmd) {
+	_ = git.WriteCDPath(wt.Path)
+	if !git.IsShellFunctionInstalled() {
+		// The wrapper may be sourced from somewhere we don't look, so the cd
+		// file is written regardless; the command is handed over as well in
+		// case it really is missing.
+		line := "cd " + shellQuote(wt.Path)
+		if copyToClipboard(line) == nil {
+			m.exitMessage = "copied " + line + " to the clipboard\n\n" + shellFallbackNote
+		} else {
+			m.exitMessage = line + "\n\n" + shellFallbackNote
+		}
+	}
+	m.touch(wt.Branch)
+	_ = git.SaveRepoState(m.repoState) // synchronously — we're about to quit
+	return m, tea.Quit
+}
+
+func (m Model) handleCDConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "c", "y":
+		if m.cursor > 0 {
+			return m.cdTo(m.worktrees[m.cursor-1])
+		}
+	case "n", "esc":
+		m.state = types.StateList
 	}
 	return m, nil
 }
@@ -447,6 +3048,18 @@ func (m Model) handleDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // ── Helpers ───────────────────────────────────────────────────────────────────
 
+// rescaleScroll maps offset from a range of [0, oldMax] onto [0, newMax],
+// clamping first so an overscrolled offset counts as the bottom.
+func rescaleScroll(offset, oldMax, newMax int) int {
+	if offset > oldMax {
+		offset = oldMax
+	}
+	if oldMax <= 0 || offset <= 0 {
+		return 0
+	}
+	return (offset*newMax + oldMax/2) / oldMax
+}
+
 func dropLast(s string) string {
 	r := []rune(s)
 	if len(r) == 0 {
@@ -458,16 +3071,21 @@ func dropLast(s string) string {
 // slugify converts a display name to a lowercase hyphenated git branch suffix.
 // Spaces, underscores and existing hyphens all become a single hyphen.
 // The slash character is preserved so "feat/something" round-trips correctly.
-func slugify(s string) string {
+// opts can keep the case and further characters, and cap the length.
+func slugify(s string, opts config.SlugOptions) string {
+	if !opts.PreserveCase {
+		s = strings.ToLower(s)
+	}
 	var b strings.Builder
 	prevSep := false
-	for _, r := range strings.ToLower(s) {
+	for _, r := range s {
 		switch {
-		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9',
+			opts.PreserveCase && r >= 'A' && r <= 'Z':
 			b.WriteRune(r)
 			prevSep = false
-		case r == '/':
-			b.WriteRune('/')
+		case r == '/' || strings.ContainsRune(opts.Keep, r):
+			b.WriteRune(r)
 			prevSep = false
 		case unicode.IsSpace(r) || r == '_' || r == '-':
 			if !prevSep && b.Len() > 0 {
@@ -476,5 +3094,9 @@ func slugify(s string) string {
 			}
 		}
 	}
-	return strings.TrimRight(b.String(), "-/")
+	slug := b.String()
+	if n := opts.MaxLength; n > 0 && len([]rune(slug)) > n {
+		slug = string([]rune(slug)[:n])
+	}
+	return strings.TrimRight(slug, "-/")
 }
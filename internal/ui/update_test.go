@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agnishcc/worktree-tui/internal/config"
+	"github.com/agnishcc/worktree-tui/internal/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chdir changes the working directory until the test ends.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(old) })
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts config.SlugOptions
+		want string
+	}{
+		{"default", "Fix Login Bug", config.SlugOptions{}, "fix-login-bug"},
+		{"separator runs", "a  _-_ b", config.SlugOptions{}, "a-b"},
+		{"slashes kept", "api/Auth Flow", config.SlugOptions{}, "api/auth-flow"},
+		{"punctuation dropped", "what's new?!", config.SlugOptions{}, "whats-new"},
+		{"leading separators", "  -hello", config.SlugOptions{}, "hello"},
+		{"trailing separators", "hello - /", config.SlugOptions{}, "hello"},
+		{"jira key lowercased", "PROJ-123 checkout", config.SlugOptions{}, "proj-123-checkout"},
+		{"jira key preserved", "PROJ-123 checkout", config.SlugOptions{PreserveCase: true}, "PROJ-123-checkout"},
+		{"keep extra", "v1.2_beta", config.SlugOptions{Keep: "._"}, "v1.2_beta"},
+		{"keep nothing extra", "v1.2_beta", config.SlugOptions{}, "v12-beta"},
+		{"max length", "a very long description", config.SlugOptions{MaxLength: 6}, "a-very"},
+		{"max length trims separator", "abc def", config.SlugOptions{MaxLength: 4}, "abc"},
+		{"max length counts runes", "ééé abc", config.SlugOptions{MaxLength: 2, Keep: "é"}, "éé"},
+		{"empty", "", config.SlugOptions{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slugify(tt.in, tt.opts); got != tt.want {
+				t.Errorf("slugify(%q, %+v) = %q, want %q", tt.in, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecalcBranch(t *testing.T) {
+	m := InitialModel(config.Default())
+	m.newTypeIdx = 0
+	m.newDisplayName = "Fix Login"
+	m.recalcBranch()
+	if want := branchTypes[0] + "/fix-login"; m.newBranch != want {
+		t.Errorf("branch = %q, want %q", m.newBranch, want)
+	}
+
+	m.newDisplayName = ""
+	m.recalcBranch()
+	if m.newBranch != branchTypes[0] {
+		t.Errorf("branch for an empty name = %q, want the bare type %q", m.newBranch, branchTypes[0])
+	}
+
+	m.cfg.Slug = config.SlugOptions{PreserveCase: true, MaxLength: 8}
+	m.newDisplayName = "PROJ-42 Checkout"
+	m.recalcBranch()
+	if want := branchTypes[0] + "/PROJ-42"; m.newBranch != want {
+		t.Errorf("branch with slug options = %q, want %q", m.newBranch, want)
+	}
+
+	m.newBranchEdited = true
+	m.newDisplayName = "something else"
+	m.recalcBranch()
+	if want := branchTypes[0] + "/PROJ-42"; m.newBranch != want {
+		t.Errorf("a hand-edited branch changed to %q", m.newBranch)
+	}
+}
+
+func TestNewWorktreeWithoutRepoRoot(t *testing.T) {
+	dir := t.TempDir()
+	// Stop git from finding a repo above the temp dir.
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	chdir(t, dir)
+
+	m := InitialModel(config.Default())
+	m.hasCommits = true
+	m.openNewModal()
+	m.newDisplayName = "x"
+	m.recalcBranch()
+	m.newActiveField = 1
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	got := next.(Model)
+	if cmd != nil {
+		t.Error("enter without a repo root returned a command; nothing should be created")
+	}
+	if !strings.Contains(got.errMsg, "repo root not found") {
+		t.Errorf("errMsg = %q, want it to say the repo root was not found", got.errMsg)
+	}
+	if got.state != types.StateNewWorktree {
+		t.Errorf("state = %v, want the form left open", got.state)
+	}
+}
+
+// listModel returns a model on the list showing a main worktree and one
+// worktree per branch, in that order.
+func listModel(branches ...string) Model {
+	m := InitialModel(config.Default())
+	m.state = types.StateList
+	m.hasCommits = true
+	m.allWorktrees = []types.Worktree{{Path: "/repo", Branch: "main", Name: "main", IsMain: true}}
+	for _, b := range branches {
+		m.allWorktrees = append(m.allWorktrees, types.Worktree{Path: "/repo/.wt/" + b, Branch: b, Name: b})
+	}
+	m.worktrees = m.visibleWorktrees()
+	return m
+}
+
+func branchesOf(wts []types.Worktree) string {
+	var bs []string
+	for _, wt := range wts {
+		bs = append(bs, wt.Branch)
+	}
+	return strings.Join(bs, " ")
+}
+
+func pressKeys(m Model, keys ...string) Model {
+	for _, k := range keys {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = next.(Model)
+	}
+	return m
+}
+
+func TestGroupWorktrees(t *testing.T) {
+	m := listModel("scratch", "fix/b", "feat/a", "wip/x", "feat/c")
+	m.grouped = true
+	m.relist()
+	// feat before fix, as in branchTypes; unknown prefixes under other,
+	// keeping git's order within each group; main stays on top.
+	if got, want := branchesOf(m.worktrees), "main feat/a feat/c fix/b scratch wip/x"; got != want {
+		t.Errorf("grouped order = %s, want %s", got, want)
+	}
+	if g := m.branchGroup(m.worktrees[5]); g != otherGroup {
+		t.Errorf("wip/x is in group %q, want %q", g, otherGroup)
+	}
+
+	m.collapsedGroups = map[string]bool{"feat": true}
+	m.relist()
+	if got, want := branchesOf(m.worktrees), "main fix/b scratch wip/x"; got != want {
+		t.Errorf("with feat collapsed = %s, want %s", got, want)
+	}
+	if n := m.groupSizes()["feat"]; n != 2 {
+		t.Errorf("collapsed feat group size = %d, want 2", n)
+	}
+}
+
+func TestGroupTemplateTypes(t *testing.T) {
+	m := listModel("spike/a", "feat/b")
+	m.cfg.Templates = []config.Template{{Name: "spike", Type: "spike"}}
+	m.grouped = true
+	m.relist()
+	if got, want := branchesOf(m.worktrees), "main feat/b spike/a"; got != want {
+		t.Errorf("grouped order = %s, want %s", got, want)
+	}
+	if g := m.branchGroup(m.worktrees[2]); g != "spike" {
+		t.Errorf("spike/a is in group %q, want spike", g)
+	}
+}
+
+func TestCollapsedGroupHeaderIsSelectable(t *testing.T) {
+	m := listModel("feat/a", "feat/b", "fix/c")
+	m = pressKeys(m, "g", "j", "j") // onto feat/a
+	if m.cursor != 2 || m.worktrees[1].Branch != "feat/a" {
+		t.Fatalf("cursor on %d, want feat/a", m.cursor)
+	}
+
+	m = pressKeys(m, "z")
+	if !m.onGroupHeader() || m.groupCursor != "feat" {
+		t.Fatalf("after collapsing, cursor=%d group=%q; want the feat header selected", m.cursor, m.groupCursor)
+	}
+	m = pressKeys(m, "j")
+	if m.onGroupHeader() || m.worktrees[m.cursor-1].Branch != "fix/c" {
+		t.Errorf("down from the header did not reach fix/c")
+	}
+	m = pressKeys(m, "k")
+	if !m.onGroupHeader() {
+		t.Errorf("up from fix/c did not return to the collapsed header")
+	}
+	m = pressKeys(m, "k")
+	if m.onGroupHeader() || m.worktrees[m.cursor-1].Branch != "main" {
+		t.Errorf("up from the header did not reach main")
+	}
+
+	m = pressKeys(m, "j", "z")
+	if m.onGroupHeader() || m.collapsedGroups["feat"] {
+		t.Fatalf("z on the header did not expand feat")
+	}
+	if got := m.worktrees[m.cursor-1].Branch; got != "feat/a" {
+		t.Errorf("after expanding, cursor on %s, want feat/a", got)
+	}
+}
+
+func TestOrderWorktrees(t *testing.T) {
+	m := listModel("a", "b", "c", "d")
+	setCommitTime := func(branch string, at int64) {
+		for i := range m.allWorktrees {
+			if m.allWorktrees[i].Branch == branch {
+				m.allWorktrees[i].Commits = []types.Commit{{Time: at}}
+			}
+		}
+	}
+	setCommitTime("main", 50)
+	setCommitTime("a", 10)
+	setCommitTime("b", 30)
+	setCommitTime("c", 20)
+	// d has no commits loaded yet and sorts last.
+	m.repoState.LastAccessed = map[string]int64{"c": 300, "a": 200, "main": 100}
+
+	tests := []struct {
+		mode   string
+		pinned []string
+		want   string
+	}{
+		{sortDefault, nil, "main a b c d"},
+		{sortCommitted, nil, "main b c a d"},
+		{sortAccessed, nil, "c a main b d"},
+		{sortDefault, []string{"d", "b"}, "d b main a c"},
+		{sortCommitted, []string{"a"}, "a main b c d"},
+	}
+	for _, tt := range tests {
+		m.repoState.SortMode = tt.mode
+		m.repoState.Pinned = tt.pinned
+		m.relist()
+		if got := branchesOf(m.worktrees); got != tt.want {
+			t.Errorf("sort %q pinned %v = %s, want %s", tt.mode, tt.pinned, got, tt.want)
+		}
+	}
+}
+
+func TestNextSortMode(t *testing.T) {
+	mode := sortDefault
+	var seen []string
+	for range sortModes {
+		mode = nextSortMode(mode)
+		seen = append(seen, mode)
+	}
+	if got, want := strings.Join(seen, ","), "committed,accessed,"; got != want {
+		t.Errorf("cycling sort modes gave %q, want %q", got, want)
+	}
+	if got := nextSortMode("bogus"); got != sortDefault {
+		t.Errorf("nextSortMode of an unknown mode = %q, want the default", got)
+	}
+}
+
+func TestSortKeepsCursorOnWorktree(t *testing.T) {
+	m := listModel("a", "b")
+	m.allWorktrees[2].Commits = []types.Commit{{Time: 100}}
+	m.worktrees = m.visibleWorktrees()
+	m.cursor = 3 // b
+	m = pressKeys(m, "s")
+	if m.repoState.SortMode != sortCommitted {
+		t.Fatalf("s set sort mode %q, want %q", m.repoState.SortMode, sortCommitted)
+	}
+	if got := m.worktrees[m.cursor-1].Branch; got != "b" {
+		t.Errorf("after sorting the cursor is on %s, want b", got)
+	}
+}
+
+func TestDropProtected(t *testing.T) {
+	m := listModel("feat/a", "release", "feat/b")
+	known := map[string]bool{"feat/a": false, "release": true}
+	got, learned := dropProtected(m.allWorktrees[1:], known, false)
+	if branchesOf(got) != "feat/a feat/b" {
+		t.Errorf("kept %q, want the unprotected branches", branchesOf(got))
+	}
+	if len(learned) != 0 {
+		t.Errorf("learned %v without gh, want nothing", learned)
+	}
+}
+
+func TestPruneCandidatesCacheProtection(t *testing.T) {
+	m := listModel("feat/a", "release")
+	next, _ := m.Update(pruneCandidatesMsg{
+		worktrees: m.allWorktrees[1:2],
+		protected: map[string]bool{"feat/a": false, "release": true},
+		finished:  true,
+	})
+	m = next.(Model)
+	if !m.protectedCache["release"] {
+		t.Error("protection learned while finding candidates was not cached")
+	}
+	if m.state != types.StatePruneMerged || !m.pruneFinished || branchesOf(m.pruneCandidates) != "feat/a" {
+		t.Errorf("state %v, finished %v, candidates %q; want the X prompt offering feat/a",
+			m.state, m.pruneFinished, branchesOf(m.pruneCandidates))
+	}
+}
+
+func TestDeleteWaitsForProtection(t *testing.T) {
+	m := listModel("feat/a")
+	m.ghAvailable = true
+	m.protectionPending = map[string]bool{"feat/a": true}
+	m.cursor = 2
+	m = pressKeys(m, "d")
+	if m.state != types.StateList || !strings.Contains(m.errMsg, "still checking") {
+		t.Fatalf("d before protection is known: state %v, errMsg %q; want it refused", m.state, m.errMsg)
+	}
+
+	next, _ := m.Update(protectionMsg{branch: "feat/a", protected: false})
+	m = next.(Model)
+	m.errMsg = ""
+	m = pressKeys(m, "d")
+	if m.state != types.StateDeleteConfirm {
+		t.Errorf("d once feat/a is known unprotected: state %v, want the delete confirmation", m.state)
+	}
+}
+
+func TestMissingRowPruneKey(t *testing.T) {
+	m := listModel("feat/gone")
+	m.allWorktrees[1].Missing = true
+	m.worktrees = m.visibleWorktrees()
+	m.cursor = 2
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if got := next.(Model); cmd != nil || !strings.Contains(got.errMsg, "x prunes") {
+		t.Errorf("p on a missing row: errMsg %q, cmd %v; want it refused and pointed at x", got.errMsg, cmd != nil)
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}); cmd == nil {
+		t.Error("x on a missing row returned no command, want the prune")
+	}
+	m.allWorktrees[1].Missing = false
+	m.worktrees = m.visibleWorktrees()
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}); cmd != nil {
+		t.Error("x on a present worktree returned a command, want nothing")
+	}
+}
@@ -2,12 +2,31 @@ package ui
 
 import (
 	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/agnishcc/worktree-tui/internal/config"
 	"github.com/agnishcc/worktree-tui/internal/types"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
+// PlainView renders m as a width×height terminal would show it, with every
+// colour and text attribute stripped, so layout can be checked without a
+// terminal (e.g. golden-file snapshots). It switches lipgloss's global colour
+// profile to ASCII for the duration of the call, so it must not run
+// alongside other rendering.
+func (m Model) PlainView(width, height int) string {
+	prev := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.Ascii)
+	defer lipgloss.SetColorProfile(prev)
+	m.width, m.height = width, height
+	return m.View()
+}
+
 func (m Model) View() string {
 	if m.width == 0 || m.height == 0 {
 		return ""
@@ -28,7 +47,7 @@ func (m Model) viewNoGit() string {
 	header := m.renderHeader()
 	body := lipgloss.NewStyle().
 		Width(m.width).
-		Height(m.height - lipgloss.Height(header)).
+		Height(m.height-lipgloss.Height(header)).
 		Align(lipgloss.Center, lipgloss.Center).
 		Render(lipgloss.JoinVertical(lipgloss.Center,
 			dimStyle.Render("No git repository found."),
@@ -52,7 +71,7 @@ func (m Model) viewShellSetup() string {
 	))
 	body := lipgloss.NewStyle().
 		Width(m.width).
-		Height(m.height - lipgloss.Height(header)).
+		Height(m.height-lipgloss.Height(header)).
 		Align(lipgloss.Center, lipgloss.Center).
 		Render(modal)
 	return lipgloss.JoinVertical(lipgloss.Left, header, body)
@@ -68,8 +87,44 @@ func (m Model) viewMain() string {
 		return m.centerModal(m.renderEditModal())
 	case types.StateDeleteConfirm:
 		return m.centerModal(m.renderDeleteModal())
-	case types.StateCommitDetail:
+	case types.StateMoveWorktree:
+		return m.centerModal(m.renderMoveModal())
+	case types.StateNotes:
+		return m.centerModal(m.renderNotesModal())
+	case types.StateRepoSwitch:
+		return m.centerModal(m.renderRepoSwitchModal())
+	case types.StateBranchSwitch:
+		return m.centerModal(m.renderBranchSwitchModal())
+	case types.StateCommitDetail, types.StateWorkingDiff:
 		return m.centerModal(m.renderCommitDetailOverlay())
+	case types.StateActivity:
+		return m.centerModal(m.renderActivityOverlay())
+	case types.StateBranchAges:
+		return m.centerModal(m.renderBranchAgesOverlay())
+	case types.StateFileTree:
+		return m.centerModal(m.renderFileTreeModal())
+	case types.StateCDConfirm:
+		return m.centerModal(m.renderCDConfirmModal())
+	case types.StateCommitMessage:
+		return m.centerModal(m.renderCommitMessageModal())
+	case types.StateCreatePR:
+		return m.centerModal(m.renderCreatePRModal())
+	case types.StatePRCheckout:
+		return m.centerModal(m.renderPRCheckoutModal())
+	case types.StateMergeConfirm:
+		return m.centerModal(m.renderMergeConfirmModal())
+	case types.StateStash:
+		return m.centerModal(m.renderStashOverlay())
+	case types.StateHookOutput:
+		return m.centerModal(m.renderHookOutputModal())
+	case types.StateGitLink:
+		return m.centerModal(m.renderGitLinkModal())
+	case types.StatePruneMerged:
+		return m.centerModal(m.renderPruneModal())
+	case types.StateLabels:
+		return m.centerModal(m.renderLabelsModal())
+	case types.StateLabelFilter:
+		return m.centerModal(m.renderLabelFilterModal())
 	}
 
 	header := m.renderHeader()
@@ -96,11 +151,22 @@ func (m Model) viewMain() string {
 }
 
 func (m Model) centerModal(modal string) string {
+	switch {
+	case m.quitArmed:
+		modal = lipgloss.JoinVertical(lipgloss.Center, modal, "", warningStyle.Render(quitPrompt))
+	case m.errMsg != "":
+		modal = lipgloss.JoinVertical(lipgloss.Center, modal, "", dangerStyle.Render(truncate("error: "+m.errMsg, m.width-4)))
+	case m.statusMsg != "":
+		modal = lipgloss.JoinVertical(lipgloss.Center, modal, "", statusStyle.Render(m.statusMsg))
+	}
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
 }
 
 // ── Header ────────────────────────────────────────────────────────────────────
 
+// spinnerFrames animate the header while every remote is being fetched.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
 func (m Model) renderHeader() string {
 	innerW := m.width - 4
 	if innerW < 4 {
@@ -111,6 +177,10 @@ func (m Model) renderHeader() string {
 	sepW := lipgloss.Width(sep)
 
 	appName := headerTextStyle.Render("⎇  worktree")
+	if m.repoName != "" {
+		// Shown so it's obvious which repo is active after switching.
+		appName += sep + headerBranchStyle.Render(m.repoName)
+	}
 
 	// Line-1 candidates (excludes fetchedAgo, which is always on line 2).
 	var candidates []string
@@ -121,7 +191,18 @@ func (m Model) renderHeader() string {
 		candidates = append(candidates, dimStyle.Render(fmt.Sprintf("%d worktrees", n)))
 	}
 	if m.stashCount > 0 {
-		candidates = append(candidates, warningStyle.Render(fmt.Sprintf("✦ %d stashed", m.stashCount)))
+		candidates = append(candidates, warningStyle.Render(fmt.Sprintf("✦ %d stashed · $ to browse", m.stashCount)))
+	}
+	if len(m.prPending) > 0 {
+		// Badges still arriving: missing ones are not "no PR" yet.
+		done, total := m.prProgress()
+		candidates = append(candidates, dimStyle.Render(fmt.Sprintf("PRs %d/%d", done, total)))
+	}
+	if m.compareBranchOverride != "" {
+		candidates = append(candidates, accentStyle.Render("⇄ comparing to "+m.compareBranchOverride))
+	}
+	if m.enclosingRepo != "" {
+		candidates = append(candidates, warningStyle.Render("nested in "+filepath.Base(m.enclosingRepo)+" · R to switch"))
 	}
 
 	// Greedily fit sections onto line 1; overflow moves to line 2 as whole units.
@@ -150,7 +231,10 @@ func (m Model) renderHeader() string {
 
 	// Build line 2: overflow sections on left, fetchedAgo always on right.
 	fetchStr := ""
-	if m.fetchedAgo != "" {
+	if m.fetchingAll {
+		frame := spinnerFrames[m.fetchSpin%len(spinnerFrames)]
+		fetchStr = accentStyle.Render(frame) + dimStyle.Render(" fetching all remotes…")
+	} else if m.fetchedAgo != "" {
 		fetchStr = dimStyle.Render("fetched " + m.fetchedAgo)
 	}
 
@@ -187,9 +271,26 @@ func (m Model) renderLeftPane(outerW, outerH int) string {
 	innerW := outerW - 2
 	innerH := outerH - 2
 
-	rows := []string{m.renderItem(0, "+ new worktree", innerW, true)}
-	for i, wt := range m.worktrees {
-		rows = append(rows, m.renderItem(i+1, wt.Name, innerW, false))
+	rows := []string{m.renderItem(0, "+ new worktree", "", innerW, true)}
+	if m.grouped {
+		rows = append(rows, m.groupedRows(innerW)...)
+	} else {
+		for i, wt := range m.worktrees {
+			name, chip := m.listItemParts(wt, (innerW-2)/2)
+			rows = append(rows, m.renderItem(i+1, name, chip, innerW, false))
+		}
+	}
+	var filters []string
+	if m.mineOnly {
+		filters = append(filters, "mine only")
+	}
+	if m.labelFilter != "" {
+		filters = append(filters, "label "+m.labelFilter)
+	}
+	if len(filters) > 0 {
+		hidden := len(m.allWorktrees) - len(m.filteredWorktrees())
+		filters = append(filters, fmt.Sprintf("%d hidden", hidden))
+		rows = append(rows, "", "  "+dimStyle.Render(strings.Join(filters, " · ")))
 	}
 
 	content := strings.Join(rows, "\n")
@@ -206,9 +307,195 @@ func (m Model) renderLeftPane(outerW, outerH int) string {
 	return style.Width(innerW).Height(innerH).Render(content)
 }
 
-func (m Model) renderItem(idx int, name string, innerW int, isNewRow bool) string {
-	selected := m.cursor == idx
+// groupedRows lays out the worktree rows under their type headers.
+// m.worktrees is already in group order, so the rows just interleave the
+// headers, and a collapsed group is a header alone, which the cursor can
+// select to expand it again.
+func (m Model) groupedRows(innerW int) []string {
+	var rows []string
+	sizes := m.groupSizes()
+	i := 0
+	for _, g := range append([]string{""}, m.groupOrder()...) {
+		if g != "" {
+			if sizes[g] == 0 {
+				continue
+			}
+			glyph := "▾"
+			if m.collapsedGroups[g] {
+				glyph = "▸"
+			}
+			header := fmt.Sprintf("%s %s (%d)", glyph, g, sizes[g])
+			if m.onGroupHeader() && m.groupCursor == g {
+				rows = append(rows, selectedAccentStyle.Render("▌")+" "+selectedItemStyle.Render(header))
+			} else {
+				rows = append(rows, "  "+dimStyle.Render(header))
+			}
+		}
+		for ; i < len(m.worktrees) && m.branchGroup(m.worktrees[i]) == g; i++ {
+			name, chip := m.listItemParts(m.worktrees[i], (innerW-2)/2)
+			if g != "" {
+				name = "  " + name
+			}
+			rows = append(rows, m.renderItem(i+1, name, chip, innerW, false))
+		}
+	}
+	return rows
+}
+
+// pinGlyph marks pinned worktrees in the list.
+const pinGlyph = "⚑"
+
+// listItemParts lays out wt's row from cfg.ListItemFormat: left is plain
+// text that renderItem styles as a whole, right is the right-aligned part
+// with each word in its own colour. Leading right-hand words are dropped
+// until it fits in maxRight columns.
+func (m Model) listItemParts(wt types.Worktree, maxRight int) (left, right string) {
+	format := m.cfg.ListItemFormat
+	if format == "" {
+		format = config.DefaultListItemFormat
+	}
+	l, r, _ := strings.Cut(format, "{>}")
+	words := m.expandListFormat(r, wt, true)
+	right = strings.Join(words, " ")
+	for len(words) > 0 && lipgloss.Width(right) > maxRight {
+		words = words[1:]
+		right = strings.Join(words, " ")
+	}
+	left = strings.Join(m.expandListFormat(l, wt, false), " ")
+	if len(wt.SharedWith) > 0 || len(wt.NameClashWith) > 0 || wt.Missing {
+		left = "⚠ " + left
+	}
+	return left, right
+}
+
+var listPlaceholderRe = regexp.MustCompile(`\{\w+\}`)
+
+// expandListFormat fills in the placeholders of one side of the list
+// format, dropping words whose placeholders are all empty.
+func (m Model) expandListFormat(format string, wt types.Worktree, styled bool) []string {
+	var words []string
+	for _, word := range strings.Fields(format) {
+		// Each label gets its own colour, so {labels} alone is special.
+		if word == "{labels}" {
+			if len(wt.Labels) > 0 {
+				words = append(words, labelChips(wt.Labels, styled))
+			}
+			continue
+		}
+		var color lipgloss.Color
+		filled, empty := 0, 0
+		word = listPlaceholderRe.ReplaceAllStringFunc(word, func(p string) string {
+			v, c := m.listField(wt, p[1:len(p)-1])
+			if v == "" {
+				empty++
+				return v
+			}
+			filled++
+			if color == "" {
+				color = c
+			}
+			return v
+		})
+		if filled == 0 && empty > 0 {
+			continue
+		}
+		if styled {
+			st := dimStyle
+			if color != "" {
+				st = lipgloss.NewStyle().Foreground(color)
+			}
+			word = st.Render(word)
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// labelPalette colours label chips; a label always gets the same colour.
+var labelPalette = []lipgloss.Color{clrBlue, clrGreen, clrYellow, clrAccent, clrPROpen, clrFlamingo, clrRed}
+
+// labelChips renders labels as "#label" chips, coloured when styled.
+func labelChips(labels []string, styled bool) string {
+	chips := make([]string, len(labels))
+	for i, l := range labels {
+		chips[i] = "#" + l
+		if styled {
+			h := fnv.New32a()
+			h.Write([]byte(l))
+			c := labelPalette[h.Sum32()%uint32(len(labelPalette))]
+			chips[i] = lipgloss.NewStyle().Foreground(c).Render(chips[i])
+		}
+	}
+	return strings.Join(chips, " ")
+}
+
+// listField resolves a ListItemFormat placeholder for wt, along with its
+// colour when right-aligned ("" for dim).
+func (m Model) listField(wt types.Worktree, key string) (string, lipgloss.Color) {
+	switch key {
+	case "name":
+		return wt.Name, ""
+	case "branch":
+		return wt.Branch, ""
+	case "pin":
+		if m.isPinned(wt.Branch) {
+			return pinGlyph, clrAccent
+		}
+	case "dirty":
+		if wt.StatusChanged+wt.StatusUntracked > 0 {
+			return "●", clrYellow
+		}
+	case "author":
+		if f := strings.Fields(wt.LastAuthor); len(f) > 0 {
+			return f[0], ""
+		}
+	case "status":
+		if r, ok := m.recommend(wt); ok {
+			return r.short, r.color
+		}
+	case "ahead":
+		if wt.Ahead > 0 {
+			return fmt.Sprintf("↑%d", wt.Ahead), clrBlue
+		}
+	case "behind":
+		if wt.Behind > 0 {
+			return fmt.Sprintf("↓%d", wt.Behind), clrYellow
+		}
+	case "pr":
+		if info := m.prCache[wt.Branch]; info != nil {
+			c := clrPRNone
+			switch strings.ToUpper(info.State) {
+			case "OPEN":
+				c = clrPROpen
+			case "MERGED":
+				c = clrPRMerged
+			case "CLOSED":
+				c = clrPRClosed
+			}
+			return fmt.Sprintf("#%d", info.Number), c
+		}
+	case "notes":
+		if wt.Notes != "" || wt.HasScratchpad {
+			return "✎", clrDim
+		}
+	case "default":
+		if wt.Branch == m.defaultBranch {
+			return "★", clrAccent
+		}
+	case "updated":
+		return wt.UpdatedAt, ""
+	}
+	return "", ""
+}
+
+// renderItem renders one list row. chip, when set, is right-aligned after
+// the name.
+func (m Model) renderItem(idx int, name, chip string, innerW int, isNewRow bool) string {
+	selected := m.cursor == idx && !m.onGroupHeader()
 	maxNameW := innerW - 2
+	if chip != "" {
+		maxNameW -= lipgloss.Width(chip) + 1
+	}
 	text := truncate(name, maxNameW)
 
 	if isNewRow {
@@ -225,10 +512,52 @@ func (m Model) renderItem(idx int, name string, innerW int, isNewRow bool) strin
 		}
 		return "  " + newItemFaintStyle.Render(padRight(text, maxNameW))
 	}
+	if chip != "" {
+		chip = " " + chip
+	}
 	if selected {
-		return selectedAccentStyle.Render("▌") + " " + selectedItemStyle.Render(padRight(text, maxNameW))
+		return selectedAccentStyle.Render("▌") + " " + selectedItemStyle.Render(padRight(text, maxNameW)) + chip
+	}
+	return "  " + normalItemStyle.Render(padRight(text, maxNameW)) + chip
+}
+
+// recommendation is the one-line verdict on what a worktree's branch needs.
+type recommendation struct {
+	short string // compact form for the list, e.g. "↓3"
+	long  string // full form for the detail pane
+	color lipgloss.Color
+}
+
+// recommend distills Ahead/Behind/IsMerged/UpstreamGone into a single
+// recommendation. ok is false where none applies (main, the default branch,
+// detached, unborn) or is not known yet (lazy mode).
+func (m Model) recommend(wt types.Worktree) (r recommendation, ok bool) {
+	def := m.compareBranch()
+	if def == "" {
+		def = "main"
+	}
+	if wt.Missing && !wt.IsMain {
+		return recommendation{"missing", "directory missing — prune to clean up", clrRed}, true
+	}
+	if !wt.Enriched || wt.IsMain || wt.Unborn || wt.Branch == "(detached)" || wt.Branch == "(bare)" || wt.Branch == def {
+		return r, false
+	}
+	switch {
+	case wt.IsMerged && wt.UpstreamGone && wt.StatusChanged+wt.StatusUntracked == 0:
+		// Provably finished: landed, remote branch deleted, nothing local.
+		return recommendation{"done", "finished — merged and upstream gone, safe to delete", clrPRMerged}, true
+	case wt.UpstreamGone || (wt.IsMerged && wt.Ahead == 0 && wt.Behind > 0):
+		// Work landed and the default branch has moved on.
+		return recommendation{"merged", "merged — clean up", clrPRMerged}, true
+	case wt.Behind > 0:
+		return recommendation{fmt.Sprintf("↓%d", wt.Behind),
+			fmt.Sprintf("needs rebase — %d behind %s", wt.Behind, def), clrYellow}, true
+	case wt.Ahead > 0:
+		return recommendation{fmt.Sprintf("↑%d", wt.Ahead),
+			fmt.Sprintf("ready to merge — %d ahead", wt.Ahead), clrBlue}, true
+	default:
+		return recommendation{"✓", "up to date", clrGreen}, true
 	}
-	return "  " + normalItemStyle.Render(padRight(text, maxNameW))
 }
 
 func (m Model) renderRightPane(outerW, outerH int) string {
@@ -236,13 +565,20 @@ func (m Model) renderRightPane(outerW, outerH int) string {
 	innerH := outerH - 2
 
 	var content string
-	if m.cursor == 0 {
+	if m.onGroupHeader() {
+		n := m.groupSizes()[m.groupCursor]
+		worktrees := "worktree"
+		if n != 1 {
+			worktrees = "worktrees"
+		}
+		content = dimStyle.Render(fmt.Sprintf("%d %s %s collapsed.\n\nPress  z  or enter to expand.", n, m.groupCursor, worktrees))
+	} else if m.cursor == 0 {
 		if !m.hasCommits {
 			content = lipgloss.JoinVertical(lipgloss.Left,
 				dimStyle.Render("Worktrees require at least one commit."),
 				"",
-				dimStyle.Render("Run  git commit  on the main branch first,"),
-				dimStyle.Render("then worktrees can be created here."),
+				dimStyle.Render("Press enter to create an empty initial commit,"),
+				dimStyle.Render("or run  git commit  on the main branch yourself."),
 			)
 		} else {
 			content = dimStyle.Render(
@@ -280,7 +616,60 @@ func (m Model) renderDetail(wt types.Worktree, innerW int) string {
 	} else {
 		sb.WriteString(title)
 	}
-	sb.WriteString("\n\n")
+	sb.WriteString("\n")
+
+	// ── Recommendation chip ────────────────────────────────────────────────────
+	if r, ok := m.recommend(wt); ok {
+		sb.WriteString(lipgloss.NewStyle().Bold(true).Foreground(r.color).Render("● " + r.long))
+		sb.WriteString("\n")
+	}
+	if line := m.mergeCheckLine(wt); line != "" {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+
+	if wt.Missing {
+		sb.WriteString(dimStyle.Render(wt.Path+" was deleted without git worktree remove — x to prune this entry") + "\n\n")
+	}
+
+	// ── In-progress operation banner ───────────────────────────────────────────
+	if wt.InProgressOp != "" {
+		sb.WriteString(dangerStyle.Render("⚠ "+wt.InProgressOp+" in progress") +
+			"  " + dimStyle.Render(inProgressHint(wt.InProgressOp)) + "\n\n")
+	}
+
+	// ── Same branch checked out elsewhere ──────────────────────────────────────
+	if len(wt.SharedWith) > 0 {
+		sb.WriteString(dangerStyle.Render("⚠ "+wt.Branch+" is also checked out in "+strings.Join(wt.SharedWith, ", ")) +
+			"\n  " + dimStyle.Render("worktrees share one branch ref — remove or switch one of them") + "\n\n")
+	}
+
+	// ── Display name used twice ────────────────────────────────────────────────
+	if len(wt.NameClashWith) > 0 {
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("⚠ the name %q is also used by %s", wt.Name, strings.Join(wt.NameClashWith, ", "))) +
+			"\n  " + dimStyle.Render("e to rename one of them so they can be told apart") + "\n\n")
+	}
+
+	// ── Unborn HEAD banner ─────────────────────────────────────────────────────
+	if wt.Unborn {
+		sb.WriteString(warningStyle.Render("○ no commits yet on "+wt.Branch) +
+			"  " + dimStyle.Render("commit here to start its history") + "\n\n")
+	}
+
+	// ── Protected on GitHub ────────────────────────────────────────────────────
+	if m.protectedCache[wt.Branch] {
+		sb.WriteString(accentStyle.Render("⛨ protected branch") +
+			"  " + dimStyle.Render("delete is disabled for this worktree") + "\n\n")
+	}
+
+	// ── Upstream gone hint ─────────────────────────────────────────────────────
+	if r, ok := m.recommend(wt); ok && r.short == "done" && !m.protectedCache[wt.Branch] {
+		sb.WriteString(lipgloss.NewStyle().Foreground(clrPRMerged).Render("✓ finished") +
+			"  " + dimStyle.Render("merged, remote branch deleted, nothing uncommitted — X removes all finished") + "\n\n")
+	} else if wt.UpstreamGone && !m.protectedCache[wt.Branch] {
+		sb.WriteString(warningStyle.Render("⊘ upstream gone") +
+			"  " + dimStyle.Render("remote branch was deleted — safe to delete (d)") + "\n\n")
+	}
 
 	// ── Metadata rows ──────────────────────────────────────────────────────────
 	ind := detailIndicatorStyle.Render("◎")
@@ -292,51 +681,9 @@ func (m Model) renderDetail(wt types.Worktree, innerW int) string {
 		))
 	}
 
-	row("Branch", detailValueStyle.Render(wt.Branch))
-	row("Path", detailValueStyle.Render(truncate(wt.Path, innerW-22)))
-	row("Updated", detailValueStyle.Render(wt.UpdatedAt))
-
-	// HEAD sha — Flamingo color.
-	if wt.HeadSHA != "" {
-		row("HEAD", lipgloss.NewStyle().Foreground(clrFlamingo).Render(wt.HeadSHA))
-	}
-
-	// Status — dirty / clean.
-	if wt.StatusChanged > 0 || wt.StatusUntracked > 0 {
-		var parts []string
-		if wt.StatusChanged > 0 {
-			parts = append(parts, lipgloss.NewStyle().Foreground(clrRed).Render("●")+
-				detailValueStyle.Render(fmt.Sprintf(" %d changed", wt.StatusChanged)))
-		}
-		if wt.StatusUntracked > 0 {
-			parts = append(parts, detailValueStyle.Render(fmt.Sprintf("%d untracked", wt.StatusUntracked)))
-		}
-		row("Status", strings.Join(parts, dimStyle.Render("  ")))
-	} else {
-		row("Status", lipgloss.NewStyle().Foreground(clrGreen).Render("✓ clean"))
-	}
-
-	// Sync — ahead/behind default branch (skip for main worktree).
-	if !wt.IsMain {
-		def := m.defaultBranch
-		if def == "" {
-			def = "main"
-		}
-		switch {
-		case wt.Ahead > 0 && wt.Behind > 0:
-			row("Sync", lipgloss.NewStyle().Foreground(clrYellow).Render(
-				fmt.Sprintf("↑%d ↓%d diverged from %s", wt.Ahead, wt.Behind, def)))
-		case wt.Ahead > 0:
-			row("Sync", detailValueStyle.Render(fmt.Sprintf("↑%d ahead of %s", wt.Ahead, def)))
-		case wt.Behind > 0:
-			row("Sync", lipgloss.NewStyle().Foreground(clrYellow).Render(
-				fmt.Sprintf("↓%d behind %s", wt.Behind, def)))
-		default:
-			row("Sync", lipgloss.NewStyle().Foreground(clrGreen).Render(fmt.Sprintf("✓ up to date with %s", def)))
-		}
-
-		if wt.CreatedFrom != "" {
-			row("Created", detailValueStyle.Render("from "+wt.CreatedFrom))
+	for _, name := range m.cfg.DetailRows {
+		if value, ok := m.detailRowValue(name, wt, innerW); ok {
+			row(name, value)
 		}
 	}
 
@@ -354,39 +701,56 @@ func (m Model) renderDetail(wt types.Worktree, innerW int) string {
 		}
 	}
 
-	// ── Commits ────────────────────────────────────────────────────────────────
-	if len(wt.Commits) > 0 {
+	// ── Notes ──────────────────────────────────────────────────────────────────
+	if wt.HasScratchpad {
+		sb.WriteString("\n" + dimStyle.Render("✎ has a scratchpad — E to open it") + "\n")
+	}
+	if wt.Notes != "" {
 		sb.WriteString("\n")
-		divW := innerW - 10
+		divW := innerW - 8 - 16
 		if divW < 3 {
 			divW = 3
 		}
+		sb.WriteString(sectionDividerStyle.Render("Notes "+strings.Repeat("─", divW)) + "  " + dimStyle.Render("N to edit notes"))
+		sb.WriteString("\n\n")
+		lines := strings.Split(wt.Notes, "\n")
+		for i, line := range lines {
+			if i == notesPreviewLines {
+				sb.WriteString(dimStyle.Render(fmt.Sprintf("… %d more lines", len(lines)-i)) + "\n")
+				break
+			}
+			sb.WriteString(dimStyle.Render(truncate(line, innerW)) + "\n")
+		}
+	}
+
+	// ── Commits ────────────────────────────────────────────────────────────────
+	commits, loaded := m.shownCommits(wt)
+	if len(commits) > 0 || m.unmergedOnly {
+		sb.WriteString("\n")
+		title := "Commits "
+		if m.unmergedOnly {
+			title = "Unmerged commits "
+		}
 		hint := ""
-		if m.state == types.StateRightPaneFocused {
+		if m.state == types.StateRightPaneFocused && len(commits) > 0 {
 			hint = "  " + dimStyle.Render("enter to view")
 		}
-		sb.WriteString(sectionDividerStyle.Render("Commits "+strings.Repeat("─", divW)) + hint)
+		divW := innerW - lipgloss.Width(title) - lipgloss.Width(hint) - 2
+		if divW < 3 {
+			divW = 3
+		}
+		sb.WriteString(sectionDividerStyle.Render(title+strings.Repeat("─", divW)) + hint)
 		sb.WriteString("\n\n")
-		for i, c := range wt.Commits {
-			maxMsg := innerW - 28
-			if maxMsg < 10 {
-				maxMsg = 10
-			}
-			selected := m.state == types.StateRightPaneFocused && i == m.selectedCommitIndex
-			if selected {
-				sb.WriteString(fmt.Sprintf("%s %s  %s  %s\n",
-					selectedAccentStyle.Render("▌"),
-					lipgloss.NewStyle().Foreground(clrFlamingo).Render(c.Hash),
-					selectedItemStyle.Render(truncate(c.Message, maxMsg)),
-					commitTimeStyle.Render(c.RelTime),
-				))
-			} else {
-				sb.WriteString(fmt.Sprintf("%s %s  %s  %s\n",
-					commitDotStyle.Render("●"),
-					commitHashStyle.Render(c.Hash),
-					commitMsgStyle.Render(truncate(c.Message, maxMsg)),
-					commitTimeStyle.Render(c.RelTime),
-				))
+		switch {
+		case !loaded:
+			sb.WriteString(dimStyle.Render("Loading…") + "\n")
+		case len(commits) == 0:
+			sb.WriteString(dimStyle.Render("no commits beyond "+m.compareBranch()) + "\n")
+		case innerW >= wideCommitListW && len(commits) > 1:
+			sb.WriteString(m.renderCommitColumns(commits, innerW))
+		default:
+			for i, c := range commits {
+				sb.WriteString(m.renderCommitRow(c, i, innerW) + "\n")
 			}
 		}
 	}
@@ -394,6 +758,239 @@ func (m Model) renderDetail(wt types.Worktree, innerW int) string {
 	return sb.String()
 }
 
+// wideCommitListW is the right-pane inner width from which the commit list
+// reflows into two columns.
+const wideCommitListW = 140
+
+// renderCommitColumns lays the commit list out in two columns: the first half
+// on the left, the rest on the right, so indices still read top-to-bottom.
+func (m Model) renderCommitColumns(commits []types.Commit, innerW int) string {
+	colW := (innerW - 2) / 2
+	split := commitColumnSplit(len(commits))
+	var left, right []string
+	for i, c := range commits {
+		row := padRight(m.renderCommitRow(c, i, colW), colW)
+		if i < split {
+			left = append(left, row)
+		} else {
+			right = append(right, row)
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		strings.Join(left, "\n"),
+		"  ",
+		strings.Join(right, "\n"),
+	) + "\n"
+}
+
+// commitColumnSplit returns the index of the first commit in the right column.
+func commitColumnSplit(n int) int {
+	return (n + 1) / 2
+}
+
+// renderCommitRow renders a single commit line fitted to width.
+func (m Model) renderCommitRow(c types.Commit, i, width int) string {
+	maxMsg := width - 28
+	refs := refChips(c.Refs, maxMsg/2)
+	if refs != "" {
+		maxMsg -= lipgloss.Width(refs) + 1
+		refs = " " + refs
+	}
+	if maxMsg < 10 {
+		maxMsg = 10
+	}
+	selected := m.state == types.StateRightPaneFocused && i == m.selectedCommitIndex
+	if selected {
+		return fmt.Sprintf("%s %s  %s%s  %s",
+			selectedAccentStyle.Render("▌"),
+			lipgloss.NewStyle().Foreground(clrFlamingo).Render(c.Hash),
+			selectedItemStyle.Render(truncate(c.Message, maxMsg)),
+			refs,
+			commitTimeStyle.Render(c.RelTime),
+		)
+	}
+	return fmt.Sprintf("%s %s  %s%s  %s",
+		commitDotStyle.Render("●"),
+		commitHashStyle.Render(c.Hash),
+		commitMsgStyle.Render(truncate(c.Message, maxMsg)),
+		refs,
+		commitTimeStyle.Render(c.RelTime),
+	)
+}
+
+// refChips renders a commit's decorations as coloured chips, at most
+// maxW columns wide; refs that don't fit are summarised as "+N".
+func refChips(refs []string, maxW int) string {
+	var chips []string
+	used := 0
+	for i, r := range refs {
+		label, c := shortRef(r)
+		chip := "(" + label + ")"
+		w := lipgloss.Width(chip) + 1
+		if used+w > maxW {
+			chips = append(chips, dimStyle.Render(fmt.Sprintf("+%d", len(refs)-i)))
+			break
+		}
+		used += w
+		chips = append(chips, lipgloss.NewStyle().Foreground(c).Render(chip))
+	}
+	return strings.Join(chips, " ")
+}
+
+// shortRef turns a full-name decoration into its display form and colour:
+// HEAD accent, tags yellow, remote-tracking branches blue, local ones green.
+func shortRef(r string) (string, lipgloss.Color) {
+	switch {
+	case strings.HasPrefix(r, "HEAD"):
+		return strings.Replace(r, "refs/heads/", "", 1), clrAccent
+	case strings.HasPrefix(r, "tag: "):
+		return strings.Replace(r, "refs/tags/", "", 1), clrYellow
+	case strings.HasPrefix(r, "refs/remotes/"):
+		return strings.TrimPrefix(r, "refs/remotes/"), clrBlue
+	}
+	return strings.TrimPrefix(r, "refs/heads/"), clrGreen
+}
+
+// rightPaneInnerW mirrors the pane width calculation in viewMain.
+func (m Model) rightPaneInnerW() int {
+	leftOuterW := m.width / 4
+	if leftOuterW < 22 {
+		leftOuterW = 22
+	}
+	return m.width - leftOuterW - 2 - 2
+}
+
+// detailRowValue renders the value for one configurable detail row. ok is
+// false when the row doesn't apply to this worktree.
+func (m Model) detailRowValue(name string, wt types.Worktree, innerW int) (value string, ok bool) {
+	// Nothing was loaded from a missing directory.
+	if wt.Missing && (name == "Updated" || name == "HEAD" || name == "Sync" || name == "Forked") {
+		return "", false
+	}
+	switch name {
+	case "Branch":
+		if wt.Tag != "" {
+			return detailValueStyle.Render("at tag "+wt.Tag) + dimStyle.Render("  (detached)"), true
+		}
+		if wt.Branch == m.defaultBranch {
+			return detailValueStyle.Render(wt.Branch) + accentStyle.Render("  ★ default"), true
+		}
+		return detailValueStyle.Render(wt.Branch), true
+
+	case "Path":
+		return detailValueStyle.Render(truncate(wt.Path, innerW-22)), true
+
+	case "Updated":
+		if wt.Unborn {
+			return dimStyle.Render("no commits yet"), true
+		}
+		return detailValueStyle.Render(wt.UpdatedAt), true
+
+	case "HEAD":
+		// HEAD sha — Flamingo color.
+		if wt.Unborn {
+			return dimStyle.Render("unborn"), true
+		}
+		if wt.HeadSHA == "" {
+			return "", false
+		}
+		return lipgloss.NewStyle().Foreground(clrFlamingo).Render(wt.HeadSHA), true
+
+	case "Status":
+		if wt.Missing {
+			return dangerStyle.Render("directory missing"), true
+		}
+		// Dirty / clean.
+		if wt.StatusChanged == 0 && wt.StatusUntracked == 0 {
+			return lipgloss.NewStyle().Foreground(clrGreen).Render("✓ clean"), true
+		}
+		var parts []string
+		if wt.StatusChanged > 0 {
+			parts = append(parts, lipgloss.NewStyle().Foreground(clrRed).Render("●")+
+				detailValueStyle.Render(fmt.Sprintf(" %d changed", wt.StatusChanged))+
+				dimStyle.Render(fmt.Sprintf(" (%d staged, %d unstaged)", wt.StatusStaged, wt.StatusUnstaged)))
+		}
+		if wt.StatusUntracked > 0 {
+			parts = append(parts, detailValueStyle.Render(fmt.Sprintf("%d untracked", wt.StatusUntracked)))
+		}
+		if wt.DirtyAge != "" {
+			parts = append(parts, dimStyle.Render("dirty for "+wt.DirtyAge))
+		}
+		return strings.Join(parts, dimStyle.Render("  ")), true
+
+	case "Sync":
+		// Ahead/behind default branch (skip for main worktree).
+		if wt.IsMain {
+			return "", false
+		}
+		def := m.compareBranch()
+		if def == "" {
+			def = "main"
+		}
+		if m.fetchingBranch == wt.Branch {
+			return dimStyle.Render("fetching " + wt.Branch + "…"), true
+		}
+		if m.pushingBranch == wt.Branch {
+			return dimStyle.Render("pushing " + wt.Branch + " to origin…"), true
+		}
+		if wt.Unborn || !m.hasCommits {
+			return dimStyle.Render("n/a — nothing to compare until both sides have commits"), true
+		}
+		switch {
+		case wt.Ahead > 0 && wt.Behind > 0:
+			return lipgloss.NewStyle().Foreground(clrYellow).Render(
+				fmt.Sprintf("↑%d ↓%d diverged from %s", wt.Ahead, wt.Behind, def)), true
+		case wt.Ahead > 0:
+			return detailValueStyle.Render(fmt.Sprintf("↑%d ahead of %s", wt.Ahead, def)), true
+		case wt.Behind > 0:
+			return lipgloss.NewStyle().Foreground(clrYellow).Render(
+				fmt.Sprintf("↓%d behind %s", wt.Behind, def)), true
+		default:
+			return lipgloss.NewStyle().Foreground(clrGreen).Render(fmt.Sprintf("✓ up to date with %s", def)), true
+		}
+
+	case "Forked":
+		// Merge-base age: an old fork point means a riskier merge.
+		if wt.IsMain || wt.MergeBase == "" {
+			return "", false
+		}
+		def := m.compareBranch()
+		if def == "" {
+			def = "main"
+		}
+		v := detailValueStyle.Render("from "+def+" "+wt.ForkedAge) +
+			dimStyle.Render(" at ") + lipgloss.NewStyle().Foreground(clrFlamingo).Render(wt.MergeBase)
+		switch {
+		case wt.Behind == 1:
+			v += dimStyle.Render(", 1 commit behind")
+		case wt.Behind > 1:
+			v += dimStyle.Render(fmt.Sprintf(", %d commits behind", wt.Behind))
+		}
+		return v, true
+
+	case "Labels":
+		if len(wt.Labels) == 0 {
+			return "", false
+		}
+		return labelChips(wt.Labels, true), true
+
+	case "Created":
+		if wt.IsMain || wt.CreatedFrom == "" {
+			return "", false
+		}
+		return detailValueStyle.Render("from " + wt.CreatedFrom), true
+	}
+	return "", false
+}
+
+// inProgressHint suggests how to finish or abandon an in-progress operation.
+func inProgressHint(op string) string {
+	if op == "bisect" {
+		return "finish with  git bisect reset"
+	}
+	return "finish with  git " + op + " --continue  or  --abort"
+}
+
 // prBadge returns the styled PR badge string for a branch, or "" if hidden.
 func (m Model) prBadge(branch string) string {
 	if !m.ghAvailable {
@@ -408,7 +1005,11 @@ func (m Model) prBadge(branch string) string {
 	}
 	switch strings.ToUpper(info.State) {
 	case "OPEN":
-		return lipgloss.NewStyle().Foreground(clrPROpen).Render(fmt.Sprintf("● open  #%d", info.Number))
+		badge := lipgloss.NewStyle().Foreground(clrPROpen).Render(fmt.Sprintf("● open  #%d", info.Number))
+		if review := reviewBadge(info.ReviewState); review != "" {
+			badge += dimStyle.Render(" · ") + review
+		}
+		return badge
 	case "MERGED":
 		return lipgloss.NewStyle().Foreground(clrPRMerged).Render(fmt.Sprintf("✓ merged  #%d", info.Number))
 	case "CLOSED":
@@ -417,52 +1018,231 @@ func (m Model) prBadge(branch string) string {
 	return ""
 }
 
+// mergeCheckLine reports whether the worktree's branch would merge cleanly
+// into the default branch, or "" while unknown or not applicable.
+func (m Model) mergeCheckLine(wt types.Worktree) string {
+	key, ok := m.mergeKey(wt)
+	if !ok {
+		return ""
+	}
+	mc, ok := m.mergeCache[key]
+	if !ok || mc.failed {
+		return ""
+	}
+	if len(mc.conflicts) == 0 {
+		return detailIndicatorStyle.Render("merges cleanly into " + m.compareBranch())
+	}
+	files := "file"
+	if len(mc.conflicts) != 1 {
+		files = "files"
+	}
+	return warningStyle.Render(fmt.Sprintf("⚠ conflicts with %s in %d %s", m.compareBranch(), len(mc.conflicts), files))
+}
+
+// reviewBadge renders an open PR's review decision, or "" when there is none.
+func reviewBadge(state string) string {
+	switch strings.ToUpper(state) {
+	case "APPROVED":
+		return lipgloss.NewStyle().Foreground(clrGreen).Render("approved")
+	case "CHANGES_REQUESTED":
+		return lipgloss.NewStyle().Foreground(clrRed).Render("changes requested")
+	case "REVIEW_REQUIRED":
+		return lipgloss.NewStyle().Foreground(clrYellow).Render("review required")
+	}
+	return ""
+}
+
 // ── Modals ────────────────────────────────────────────────────────────────────
 
+// modalHelp returns the inline help for the open modal, one line per
+// entry, or nil when the current state has none.
+func modalHelp(m Model) []string {
+	switch m.state {
+	case types.StateNewWorktree:
+		if !m.hasCommits {
+			return nil
+		}
+		if m.newTypeListOpen {
+			return []string{
+				"A type sets the branch prefix, e.g. feat/ → feat/my-change.",
+				"Templates (from config) also pre-fill the description, base,",
+				"path, and commands to run once the worktree exists.",
+			}
+		}
+		if m.newBranchOpen {
+			return []string{
+				"Local branches not checked out in any worktree. The new",
+				"worktree checks the chosen one out as it is, without -b.",
+			}
+		}
+		return []string{
+			"Type    branch prefix; enter opens the picker (templates too).",
+			"Name    display label only — spaces and any text are fine.",
+			"Branch  follows type + name until you edit it yourself;",
+			"        spaces become hyphens. ctrl+r re-links it;",
+			"        ctrl+b picks an existing branch without a worktree.",
+			"Description  optional, shown in the detail pane.",
+			"Base    tag, SHA or branch to start from; empty uses HEAD",
+			"        (or the template's base).",
+			"Created at <repo>/.wt/<branch>, with / in the branch as -.",
+			"ctrl+y  copies the equivalent git worktree add command.",
+		}
+	case types.StateEditWorktree:
+		return []string{
+			"Type    ←→ swaps the branch's type prefix, keeping the rest.",
+			"Name and description are stored in worktree-tui metadata.",
+			"Changing the branch renames it with git branch -m. A worktree",
+			"in its default .wt/<branch> directory moves along with it.",
+			"u in the list undoes the last metadata edit.",
+		}
+	case types.StateDeleteConfirm:
+		return []string{
+			"Runs git worktree remove --force: the directory goes, along",
+			"with any uncommitted changes. The branch itself is kept.",
+		}
+	case types.StateMoveWorktree:
+		return []string{
+			"Runs git worktree move. Relative paths and ~ are resolved",
+			"against the repo root; missing parent directories are created.",
+		}
+	case types.StateNotes:
+		return []string{
+			"Free-form notes, kept per branch in worktree-tui metadata.",
+			"The first lines show in the detail pane.",
+		}
+	case types.StateCommitMessage:
+		return []string{
+			"Commits what is staged; ctrl+a adds -a to include every",
+			"tracked change. Untracked files are never included.",
+			"enter on the subject commits; in the body it adds a line.",
+		}
+	case types.StateCreatePR:
+		return []string{
+			"Runs gh pr create against the branch's remote. Push it",
+			"first (p) if gh says the branch is not on the remote.",
+			"enter on the title creates; in the body it adds a line.",
+		}
+	case types.StatePRCheckout:
+		return []string{
+			"Lists open PRs with gh. A PR's branch is checked out into a",
+			"new worktree tracking origin; one from a fork becomes pr/<n>.",
+		}
+	case types.StateLabels:
+		return []string{
+			"Free-form tags such as blocked, review, hotfix. They show",
+			"as chips in the list and detail pane; l filters by one.",
+			"Stored per branch in worktree-tui metadata.",
+		}
+	case types.StateLabelFilter:
+		return []string{
+			"Shows only worktrees carrying the label (main stays listed).",
+			"Pick all to clear it. Combines with the a author filter.",
+		}
+	case types.StateRepoSwitch:
+		return []string{
+			"Repos are registered the first time you open them.",
+			"Typing filters by fuzzy match on the path.",
+		}
+	case types.StateBranchSwitch:
+		if m.branchTarget != nil {
+			return []string{
+				"The branch has no worktree yet; one is created at the path",
+				"shown. Remote branches get a local tracking branch.",
+			}
+		}
+		return []string{
+			"Lists local and remote branches. Picking one with a worktree",
+			"jumps to it; otherwise you're offered to create one.",
+		}
+	}
+	return nil
+}
+
+// helpRows renders the open modal's inline help, followed by a spacer, or
+// nothing while it is collapsed.
+func (m Model) helpRows() []string {
+	if !m.modalHelpOpen {
+		return nil
+	}
+	var rows []string
+	for _, line := range modalHelp(m) {
+		rows = append(rows, dimStyle.Render(line))
+	}
+	return append(rows, "")
+}
+
 // renderNewModal switches between the type-picker overlay and the main form.
 func (m Model) renderNewModal() string {
 	if m.newTypeListOpen {
 		return m.renderTypeListModal()
 	}
+	if m.newBranchOpen {
+		return m.renderExistingBranchModal()
+	}
 	return m.renderNewFormModal()
 }
 
+// renderExistingBranchModal lists the local branches a new worktree can
+// attach to.
+func (m Model) renderExistingBranchModal() string {
+	content := []string{
+		modalTitleStyle.Render("Existing Branch"),
+		"",
+		m.newBranchPicker.view(50),
+		"",
+	}
+	content = append(content, m.helpRows()...)
+	content = append(content, m.renderHints("type  filter", "↑↓  navigate", "enter  select", "?  help", "esc  close"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, content...))
+}
+
 // renderTypeListModal renders the branch-type selection overlay.
 func (m Model) renderTypeListModal() string {
 	var rows []string
-	for i, t := range branchTypes {
-		if i == m.newTypeIdx {
-			rows = append(rows, selectedAccentStyle.Render("▌")+" "+selectedItemStyle.Render(t))
+	row := func(i int, label string) {
+		if i == m.newTypeCursor {
+			rows = append(rows, selectedAccentStyle.Render("▌")+" "+selectedItemStyle.Render(label))
 		} else {
-			rows = append(rows, "  "+dimStyle.Render(t))
+			rows = append(rows, "  "+dimStyle.Render(label))
 		}
 	}
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	for i, t := range branchTypes {
+		row(i, t)
+	}
+	if len(m.cfg.Templates) > 0 {
+		rows = append(rows, "", modalLabelStyle.Render("Templates"))
+		for i, t := range m.cfg.Templates {
+			row(len(branchTypes)+i, t.Name)
+		}
+	}
+	content := []string{
 		modalTitleStyle.Render("Select Type"),
 		"",
 		strings.Join(rows, "\n"),
 		"",
-		m.renderHints("↑↓  navigate", "enter  select", "esc  close"),
-	)
-	return modalStyle.Render(content)
+	}
+	content = append(content, m.helpRows()...)
+	content = append(content, m.renderHints("↑↓  navigate", "enter  select", "?  help", "esc  close"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, content...))
 }
 
-// renderNoCommitsModal is shown instead of the create form when the repo has no commits.
+// renderNoCommitsModal replaces the create form when the repo has no commits,
+// offering to make an empty initial one.
 func (m Model) renderNoCommitsModal() string {
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		modalTitleStyle.Render("New Worktree"),
 		"",
-		dangerStyle.Render("✗  Cannot create worktree"),
+		warningStyle.Render("No commits yet"),
 		"",
-		dimStyle.Render("No commits on main yet."),
-		dimStyle.Render("Make an initial commit first."),
+		dimStyle.Render("Worktrees branch off a commit, and this repo has none."),
+		dimStyle.Render("Create an empty initial commit now?"),
 		"",
-		m.renderHints("esc  close"),
+		m.renderHints("enter/y  create it", "esc  cancel"),
 	)
 	return modalStyle.Render(content)
 }
 
-// renderNewFormModal renders the four-field create form.
+// renderNewFormModal renders the five-field create form.
 func (m Model) renderNewFormModal() string {
 	if !m.hasCommits {
 		return m.renderNoCommitsModal()
@@ -476,6 +1256,9 @@ func (m Model) renderNewFormModal() string {
 
 	// Type field (not a text input — uses picker).
 	typeVal := branchTypes[m.newTypeIdx]
+	if m.newTemplate != nil {
+		typeVal += " · template " + m.newTemplate.Name
+	}
 	var typeDisplay string
 	if m.newActiveField == 0 {
 		typeDisplay = selectedItemStyle.Render(typeVal) + "  " + dimStyle.Render("↵ change")
@@ -486,12 +1269,12 @@ func (m Model) renderNewFormModal() string {
 	// Hints depend on which field is focused.
 	var hints string
 	if m.newActiveField == 0 {
-		hints = m.renderHints("enter  change type", "tab/↑↓  navigate", "esc  cancel")
+		hints = m.renderHints("enter  change type", "tab/↑↓  navigate", "?  help", "esc  cancel")
 	} else {
-		hints = m.renderHints("enter  create", "tab/↑↓  navigate", "esc  cancel")
+		hints = m.renderHints("enter  create", "tab/↑↓  navigate", "ctrl+b  existing branch", "?  help", "esc  cancel")
 	}
 
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	rows := []string{
 		modalTitleStyle.Render("New Worktree"),
 		"",
 		fieldLabel("Type", 0),
@@ -502,25 +1285,82 @@ func (m Model) renderNewFormModal() string {
 		"",
 		fieldLabel("Branch", 2),
 		m.fieldInput(m.newBranch, m.newActiveField == 2),
+	}
+	switch {
+	case m.newExisting != "":
+		rows = append(rows, dimStyle.Render("existing branch, no new one is made · ctrl+r to follow the name again"))
+	case m.newBranchEdited:
+		rows = append(rows, dimStyle.Render("edited by hand · ctrl+r to follow the name again"))
+	}
+	if len(m.newWarnings) > 0 && m.newWarnedFor == m.newBranch {
+		rows = append(rows, warningRows(m.newWarnings)...)
+		rows = append(rows, dimStyle.Render("press enter again to create anyway"))
+	}
+	rows = append(rows,
 		"",
 		fieldLabel("Description", 3),
 		m.fieldInput(m.newDescription, m.newActiveField == 3),
 		"",
-		hints,
+		fieldLabel("Base", 4),
 	)
-	return modalStyle.Render(content)
+	// An empty base falls back to the template's, then HEAD.
+	switch {
+	case m.newExisting != "":
+		rows = append(rows, dimStyle.Render("— the existing branch is checked out as it is"))
+	case m.newBase == "" && m.newActiveField != 4:
+		base := "HEAD"
+		if t := m.newTemplate; t != nil && t.Base != "" {
+			base = t.Base + " (template)"
+		}
+		rows = append(rows, dimStyle.Render(base))
+	default:
+		rows = append(rows, m.fieldInput(m.newBase, m.newActiveField == 4))
+	}
+	if m.newBaseFrom != "" {
+		rows = append(rows, dimStyle.Render("starts at ")+accentStyle.Render(m.newBaseFrom))
+	}
+	rows = append(rows, "")
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, hints)
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
 }
 
 func (m Model) renderEditModal() string {
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	fieldLabel := func(label string, idx int) string {
+		if m.editActiveField == idx {
+			return accentStyle.Render(label)
+		}
+		return modalLabelStyle.Render(label)
+	}
+	typeVal := "none"
+	if m.editTypeIdx >= 0 {
+		typeVal = branchTypes[m.editTypeIdx]
+	}
+	var typeDisplay string
+	if m.editActiveField == 0 {
+		typeDisplay = selectedItemStyle.Render(typeVal) + "  " + dimStyle.Render("←→ change")
+	} else {
+		typeDisplay = dimStyle.Render(typeVal)
+	}
+	rows := []string{
 		modalTitleStyle.Render("Edit Worktree"),
 		"",
-		modalLabelStyle.Render("Branch name"),
-		m.fieldInput(m.editName, true),
+		fieldLabel("Type", 0),
+		typeDisplay,
 		"",
-		m.renderHints("enter  save", "esc  cancel"),
-	)
-	return modalStyle.Render(content)
+		fieldLabel("Name", 1),
+		m.fieldInput(m.editDisplayName, m.editActiveField == 1),
+		"",
+		fieldLabel("Branch", 2),
+		m.fieldInput(m.editBranch, m.editActiveField == 2),
+		"",
+		fieldLabel("Description", 3),
+		m.fieldInput(m.editDescription, m.editActiveField == 3),
+		"",
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("enter  save", "tab/↑↓  navigate", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
 }
 
 func (m Model) renderDeleteModal() string {
@@ -528,14 +1368,468 @@ func (m Model) renderDeleteModal() string {
 	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
 		name = m.worktrees[m.cursor-1].Name
 	}
-	content := lipgloss.JoinVertical(lipgloss.Left,
-		dangerStyle.Render("Delete "+name+"?"),
+	rows := []string{
+		dangerStyle.Render("Delete " + name + "?"),
 		"",
 		dimStyle.Render("This cannot be undone."),
 		"",
-		m.renderHints("y  confirm", "n / esc  cancel"),
-	)
-	return modalStyle.Render(content)
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("y  confirm", "n / esc  cancel", "?  help"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderCDConfirmModal() string {
+	name, path := "", ""
+	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
+		name, path = m.worktrees[m.cursor-1].Name, m.worktrees[m.cursor-1].Path
+	}
+	rows := []string{
+		modalTitleStyle.Render("cd to " + name + "?"),
+		"",
+		accentStyle.Render("→ ") + path,
+		"",
+		m.renderHints("enter/c  cd and quit", "n / esc  cancel"),
+	}
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderMoveModal() string {
+	name := ""
+	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
+		name = m.worktrees[m.cursor-1].Name
+	}
+	rows := []string{
+		modalTitleStyle.Render("Move " + name),
+		"",
+		modalLabelStyle.Render("New path"),
+		m.fieldInput(m.moveDest, true),
+	}
+	if m.moveErr != "" {
+		rows = append(rows, "", warningStyle.Render("⚠ "+m.moveErr))
+	}
+	rows = append(rows, "")
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("enter  move", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// notesPreviewLines is how many note lines the detail pane shows.
+const notesPreviewLines = 3
+
+// notesModalW is the text width of the notes overlay.
+const notesModalW = 60
+
+func (m Model) renderNotesModal() string {
+	name := ""
+	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
+		name = m.worktrees[m.cursor-1].Name
+	}
+	var lines []string
+	for _, para := range strings.Split(m.notesDraft, "\n") {
+		if para == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, wrapWords(para, notesModalW)...)
+	}
+	// Cursor sits at the end of the draft.
+	lines[len(lines)-1] = modalInputStyle.Render(lines[len(lines)-1]) + accentStyle.Render("█")
+	for len(lines) < 8 {
+		lines = append(lines, "")
+	}
+	rows := []string{
+		modalTitleStyle.Render("Notes — " + name),
+		"",
+		lipgloss.NewStyle().Width(notesModalW).Render(strings.Join(lines, "\n")),
+		"",
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("enter  newline", "ctrl+s  save", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderCommitMessageModal() string {
+	fieldLabel := func(label string, idx int) string {
+		if m.commitActiveField == idx {
+			return accentStyle.Render(label)
+		}
+		return modalLabelStyle.Render(label)
+	}
+	var body []string
+	for _, line := range strings.Split(m.commitBody, "\n") {
+		body = append(body, truncate(line, notesModalW))
+	}
+	last := len(body) - 1
+	if m.commitActiveField == 1 {
+		body[last] = modalInputStyle.Render(body[last]) + accentStyle.Render("█")
+	} else {
+		body[last] = dimStyle.Render(body[last])
+	}
+	for len(body) < 4 {
+		body = append(body, "")
+	}
+	all := "○ stage all tracked changes (-a)"
+	if m.commitAll {
+		all = "● stage all tracked changes (-a)"
+	}
+	rows := []string{
+		modalTitleStyle.Render("Commit"),
+		"",
+		fieldLabel("Subject", 0),
+		m.fieldInput(m.commitSubject, m.commitActiveField == 0),
+		"",
+		fieldLabel("Body (optional)", 1),
+		lipgloss.NewStyle().Width(notesModalW).Render(strings.Join(body, "\n")),
+		"",
+		dimStyle.Render(all),
+	}
+	if m.commitErr != "" {
+		rows = append(rows, "", dangerStyle.Render("✗ "+m.commitErr))
+	}
+	rows = append(rows, "")
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("ctrl+s  commit", "tab  field", "ctrl+a  toggle -a", "?  help", "esc  back"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderCreatePRModal() string {
+	fieldLabel := func(label string, idx int) string {
+		if m.prActiveField == idx {
+			return accentStyle.Render(label)
+		}
+		return modalLabelStyle.Render(label)
+	}
+	var body []string
+	for _, line := range strings.Split(m.prBody, "\n") {
+		body = append(body, truncate(line, notesModalW))
+	}
+	last := len(body) - 1
+	if m.prActiveField == 1 {
+		body[last] = modalInputStyle.Render(body[last]) + accentStyle.Render("█")
+	} else {
+		body[last] = dimStyle.Render(body[last])
+	}
+	for len(body) < 4 {
+		body = append(body, "")
+	}
+	draft := "○ draft"
+	if m.prDraft {
+		draft = "● draft"
+	}
+	rows := []string{
+		modalTitleStyle.Render("Create PR — " + m.prBranch),
+		"",
+		fieldLabel("Title", 0),
+		m.fieldInput(m.prTitle, m.prActiveField == 0),
+		"",
+		fieldLabel("Body (optional)", 1),
+		lipgloss.NewStyle().Width(notesModalW).Render(strings.Join(body, "\n")),
+		"",
+		dimStyle.Render(draft),
+	}
+	if m.prCreating {
+		rows = append(rows, "", dimStyle.Render("creating…"))
+	}
+	if m.prErr != "" {
+		rows = append(rows, "", dangerStyle.Render("✗ "+m.prErr))
+	}
+	rows = append(rows, "")
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("ctrl+s  create", "tab  field", "ctrl+d  toggle draft", "?  help", "esc  back"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderLabelsModal() string {
+	name := ""
+	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
+		name = m.worktrees[m.cursor-1].Name
+	}
+	rows := []string{
+		modalTitleStyle.Render("Labels — " + name),
+		"",
+		modalLabelStyle.Render("Comma-separated"),
+		m.fieldInput(m.labelsDraft, true),
+	}
+	if labels := parseLabels(m.labelsDraft); len(labels) > 0 {
+		rows = append(rows, "", labelChips(labels, true))
+	}
+	rows = append(rows, "")
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("enter  save", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderLabelFilterModal() string {
+	rows := []string{
+		modalTitleStyle.Render("Filter by Label"),
+		"",
+		m.labelPicker.view(60),
+		"",
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("type  filter", "↑↓  navigate", "enter  apply", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderPruneModal() string {
+	var rows []string
+	if m.pruneResults == nil {
+		kind, why := "merged", "Merged, with nothing uncommitted or unpushed:"
+		if m.pruneFinished {
+			kind, why = "finished", "Merged, remote branch deleted, nothing uncommitted:"
+		}
+		title := fmt.Sprintf("Delete %d %s worktrees?", len(m.pruneCandidates), kind)
+		if len(m.pruneCandidates) == 1 {
+			title = "Delete 1 " + kind + " worktree?"
+		}
+		rows = append(rows,
+			modalTitleStyle.Render(title),
+			"",
+			dimStyle.Render(why),
+		)
+		for _, wt := range m.pruneCandidates {
+			rows = append(rows, "  "+wt.Name+"  "+dimStyle.Render(wt.Path))
+		}
+		rows = append(rows, "", dimStyle.Render("Branches are kept."), "",
+			m.renderHints("y  delete all", "n / esc  keep"))
+		return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	}
+	rows = append(rows, modalTitleStyle.Render("Prune results"), "")
+	for _, r := range m.pruneResults {
+		if r.err != nil {
+			rows = append(rows, dangerStyle.Render("✗ "+r.name)+"  "+dimStyle.Render(r.err.Error()))
+		} else {
+			rows = append(rows, detailIndicatorStyle.Render("✓ ")+r.name)
+		}
+	}
+	rows = append(rows, "", m.renderHints("any key  close"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderGitLinkModal() string {
+	name, path := "", ""
+	if m.cursor > 0 && m.cursor-1 < len(m.worktrees) {
+		name, path = m.worktrees[m.cursor-1].Name, m.worktrees[m.cursor-1].Path
+	}
+	l := m.gitLink
+	field := func(label, value string) []string {
+		if value == "" {
+			value = dimStyle.Render("—")
+		}
+		return []string{modalLabelStyle.Render(label), value, ""}
+	}
+	rows := []string{modalTitleStyle.Render(".git link — " + name), ""}
+	rows = append(rows, field("Worktree", path)...)
+	if l.DotGit == "" && len(l.Problems) == 0 {
+		rows = append(rows, field(".git", dimStyle.Render("directory (main worktree)"))...)
+	} else {
+		rows = append(rows, field(".git file", l.DotGit)...)
+	}
+	rows = append(rows, field("Admin dir", l.AdminDir)...)
+	if l.DotGit != "" {
+		rows = append(rows, field("Admin gitdir", l.BackLink)...)
+	}
+	rows = append(rows, field("HEAD", l.Head)...)
+	if l.LockReason != "" {
+		rows = append(rows, field("Locked", warningStyle.Render(l.LockReason))...)
+	}
+	if len(l.Problems) == 0 {
+		rows = append(rows, detailIndicatorStyle.Render("✓ links are consistent"))
+	}
+	for _, p := range l.Problems {
+		rows = append(rows, dangerStyle.Render("✗ "+p))
+	}
+	rows = append(rows, "", m.renderHints("esc  close"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// hookOutputHeight is how many lines of hook output the overlay shows.
+func (m Model) hookOutputHeight() int {
+	return max(m.height-16, 5)
+}
+
+// renderHookOutputModal shows what git's hooks printed while creating the
+// selected worktree, scrolled by hookScroll.
+func (m Model) renderHookOutputModal() string {
+	w := max(min(m.width-12, 100), 30)
+	rows := []string{modalTitleStyle.Render("Hook output — " + filepath.Base(m.hookPath)), ""}
+	if m.hookErr != "" {
+		rows = append(rows, dangerStyle.Render(truncate("✗ "+m.hookErr, w)), "")
+	} else {
+		rows = append(rows, detailIndicatorStyle.Render("✓ worktree created"), "")
+	}
+	lines := strings.Split(m.hookOut, "\n")
+	h := m.hookOutputHeight()
+	end := min(m.hookScroll+h, len(lines))
+	for _, l := range lines[m.hookScroll:end] {
+		rows = append(rows, dimStyle.Render(truncate(l, w)))
+	}
+	hints := []string{"enter  close"}
+	if len(lines) > h {
+		hints = append([]string{"↑↓  scroll"}, hints...)
+		rows = append(rows, dimStyle.Render(fmt.Sprintf("lines %d–%d of %d", m.hookScroll+1, end, len(lines))))
+	}
+	rows = append(rows, "", m.renderHints(hints...))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// fileTreeHeight is how many rows of the file tree overlay fit on screen.
+func (m Model) fileTreeHeight() int {
+	return max(m.height-12, 5)
+}
+
+// renderFileTreeModal shows the top levels of fileTreePath's files,
+// scrolled by fileTreeScroll.
+func (m Model) renderFileTreeModal() string {
+	w := max(min(m.width-12, 80), 30)
+	rows := []string{modalTitleStyle.Render("Files — " + filepath.Base(m.fileTreePath)), ""}
+	h := m.fileTreeHeight()
+	switch {
+	case m.fileTree == nil:
+		rows = append(rows, dimStyle.Render("Loading…"))
+	case len(m.fileTree) == 0:
+		rows = append(rows, dimStyle.Render("No files."))
+	}
+	end := min(m.fileTreeScroll+h, len(m.fileTree))
+	for _, e := range m.fileTree[m.fileTreeScroll:end] {
+		indent := strings.Repeat("  ", e.depth)
+		if strings.HasSuffix(e.name, "/") {
+			rows = append(rows, indent+headerBranchStyle.Render(truncate(e.name, w-len(indent)-12))+
+				dimStyle.Render(fmt.Sprintf(" (%d)", e.files)))
+		} else {
+			rows = append(rows, indent+truncate(e.name, w-len(indent)))
+		}
+	}
+	hints := []string{"esc  close"}
+	if len(m.fileTree) > h {
+		hints = append([]string{"↑↓/pgup/pgdn  scroll"}, hints...)
+		rows = append(rows, "", dimStyle.Render(fmt.Sprintf("rows %d–%d of %d", m.fileTreeScroll+1, end, len(m.fileTree))))
+	}
+	rows = append(rows, "", m.renderHints(hints...))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderRepoSwitchModal() string {
+	rows := []string{
+		modalTitleStyle.Render("Switch Repo"),
+		"",
+		m.repoPicker.view(60),
+		"",
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("type  filter", "↑↓  navigate", "enter  switch", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderBranchSwitchModal() string {
+	if t := m.branchTarget; t != nil {
+		action := "Create a worktree for " + t.ref.Name + "?"
+		if t.ref.Remote != "" {
+			action = "Create a worktree tracking " + t.ref.Ref() + "?"
+		}
+		rows := []string{
+			modalTitleStyle.Render("Switch Branch"),
+			"",
+			action,
+			dimStyle.Render("at " + t.path),
+			"",
+		}
+		if len(m.branchWarnings) > 0 {
+			rows = append(rows, warningRows(m.branchWarnings)...)
+			rows = append(rows, "")
+		}
+		rows = append(rows, m.helpRows()...)
+		rows = append(rows, m.renderHints("y/enter  create", "n/esc  back", "?  help"))
+		return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	}
+	rows := []string{
+		modalTitleStyle.Render("Switch Branch"),
+		"",
+		m.branchPicker.view(60),
+		"",
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("type  filter", "↑↓  navigate", "enter  switch / create", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderMergeConfirmModal() string {
+	src, dst := m.mergeSource, m.mergeTarget
+	rows := []string{
+		modalTitleStyle.Render("Merge " + src.Branch + " into " + dst.Branch + "?"),
+		"",
+		dimStyle.Render("in " + dst.Path),
+		"",
+	}
+	p := m.mergePlan
+	canMerge := false
+	switch {
+	case p == nil:
+		rows = append(rows, dimStyle.Render("checking for conflicts…"))
+	case p.err != nil:
+		rows = append(rows, dangerStyle.Render("✗ "+p.err.Error()))
+	case p.ahead == 0:
+		rows = append(rows, dimStyle.Render("Nothing to merge — "+dst.Branch+" already has every commit."))
+	case len(p.conflicts) > 0:
+		files := "file"
+		if len(p.conflicts) != 1 {
+			files = "files"
+		}
+		rows = append(rows, warningStyle.Render(fmt.Sprintf("⚠ would conflict in %d %s:", len(p.conflicts), files)))
+		for _, f := range p.conflicts {
+			rows = append(rows, "  "+f)
+		}
+		rows = append(rows, "", dimStyle.Render("Rebase "+src.Branch+" onto "+dst.Branch+" and resolve them first."))
+	default:
+		canMerge = true
+		commits := "1 commit"
+		if p.ahead != 1 {
+			commits = fmt.Sprintf("%d commits", p.ahead)
+		}
+		how := "a merge commit"
+		if p.fastForward {
+			how = "a fast-forward"
+		}
+		rows = append(rows,
+			detailIndicatorStyle.Render("✓ merges cleanly"),
+			"",
+			fmt.Sprintf("Brings %s into %s as %s.", commits, dst.Branch, how),
+			dimStyle.Render("Nothing is pushed."),
+		)
+	}
+	rows = append(rows, "")
+	if canMerge {
+		rows = append(rows, m.renderHints("y  merge", "n / esc  cancel"))
+	} else {
+		rows = append(rows, m.renderHints("esc  close"))
+	}
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m Model) renderPRCheckoutModal() string {
+	rows := []string{modalTitleStyle.Render("Check out PR"), ""}
+	if m.prList == nil {
+		rows = append(rows, dimStyle.Render("loading open PRs…"), "")
+	} else {
+		rows = append(rows, m.prPicker.view(60), "")
+		if i := m.prPicker.selected(); i >= 0 && m.prList[i].Number == m.prWarnedFor && len(m.prWarnings) > 0 {
+			rows = append(rows, warningRows(m.prWarnings)...)
+			rows = append(rows, dimStyle.Render("press enter again to check out anyway"), "")
+		}
+	}
+	rows = append(rows, m.helpRows()...)
+	rows = append(rows, m.renderHints("type  filter", "↑↓  navigate", "enter  check out / switch", "?  help", "esc  cancel"))
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// warningRows renders each of warnings as a "⚠" line.
+func warningRows(warnings []string) []string {
+	rows := make([]string, len(warnings))
+	for i, w := range warnings {
+		rows[i] = warningStyle.Render("⚠ " + w)
+	}
+	return rows
 }
 
 // fieldInput renders an input line. When active it shows a block cursor.
@@ -546,10 +1840,11 @@ func (m Model) fieldInput(value string, active bool) string {
 	return dimStyle.Render(value + " ")
 }
 
-// renderCommitDetailOverlay renders the Level 3 centered modal.
-func (m Model) renderCommitDetailOverlay() string {
-	outerW := m.width * 80 / 100
-	outerH := m.height * 80 / 100
+// commitDetailSize returns the Level 3 overlay's inner width and the height
+// of its scrollable region for the current terminal size.
+func (m Model) commitDetailSize() (innerW, scrollH int) {
+	outerW := m.width * m.cfg.OverlayWidthPct / 100
+	outerH := m.height * m.cfg.OverlayHeightPct / 100
 	if outerW < 40 {
 		outerW = 40
 	}
@@ -557,26 +1852,413 @@ func (m Model) renderCommitDetailOverlay() string {
 		outerH = 10
 	}
 	// Border (1 each side) + Padding (2 left/right, 1 top/bottom).
-	innerW := outerW - 6
+	innerW = outerW - 6
 	innerH := outerH - 4
 
 	// Reserve 2 lines at the bottom for blank line + footer hints.
-	scrollH := innerH - 2
+	scrollH = innerH - 2
 	if scrollH < 1 {
 		scrollH = 1
 	}
+	return innerW, scrollH
+}
 
+// commitDetailMaxScroll is the largest useful commitDetailScroll.
+func (m Model) commitDetailMaxScroll() int {
+	innerW, scrollH := m.commitDetailSize()
+	if max := len(m.commitDetailLines(innerW)) - scrollH; max > 0 {
+		return max
+	}
+	return 0
+}
+
+// renderCommitDetailOverlay renders the Level 3 centered modal.
+func (m Model) renderCommitDetailOverlay() string {
+	if m.commitSplit && m.state == types.StateCommitDetail {
+		return m.renderCommitSplit()
+	}
+	innerW, scrollH := m.commitDetailSize()
+	lines := m.commitDetailLines(innerW)
+
+	// ── Apply scroll ───────────────────────────────────────────────────────
+	total := len(lines)
+	maxScroll := total - scrollH
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	scroll := m.commitDetailScroll
+	if scroll > maxScroll {
+		scroll = maxScroll
+	}
+	visible := lines
+	if scroll > 0 && scroll < len(lines) {
+		visible = lines[scroll:]
+	}
+	if len(visible) > scrollH {
+		visible = visible[:scrollH]
+	}
+	for len(visible) < scrollH {
+		visible = append(visible, "")
+	}
+
+	// ── Scroll indicator ───────────────────────────────────────────────────
+	// Append a simple N/M indicator when content overflows.
+	scrollInfo := ""
+	if total > scrollH {
+		scrollInfo = "  " + dimStyle.Render(fmt.Sprintf("%d/%d", scroll+1, total))
+	}
+
+	algo := m.diffAlgorithm
+	if algo == "" {
+		algo = "default"
+	}
+	ws := "W  ignore ws"
+	if m.diffIgnoreWS {
+		ws = "W  ws ignored"
+	}
+	var hints string
+	if m.state == types.StateWorkingDiff {
+		hints = m.renderHints("↑↓/pgup/pgdn  scroll", "tab  file", "a/u  stage/unstage", "c  commit", ws, "Y  copy files", "esc  close") + scrollInfo
+	} else {
+		keys := []string{"↑↓/pgup/pgdn  scroll", "g/G  top/bottom", "a  diff: " + algo, ws, "s  split", "tab  file", "o/O  open file/working copy", "y/Y  copy link/files", "esc  close"}
+		if m.activeCommit.Root {
+			keys = append(keys[:len(keys)-1], "d  files & diff", "esc  close")
+		}
+		hints = m.renderHints(keys...) + scrollInfo
+	}
+	body := strings.Join(visible, "\n") + "\n\n" + hints
+
+	return modalStyle.Width(innerW).Render(body)
+}
+
+// commitSplitSize lays out the split commit overlay inside the normal one:
+// the file column's width, the diff column's width, and their shared height.
+func (m Model) commitSplitSize() (listW, diffW, h int) {
+	innerW, scrollH := m.commitDetailSize()
+	longest := 0
+	for _, f := range m.activeCommit.Files {
+		longest = max(longest, lipgloss.Width(f.Path))
+	}
+	listW = min(max(longest+4, 20), innerW/3) // "▌M " + path
+	diffW = max(innerW-listW-3, 10)           // " │ " between the columns
+	h = max(scrollH-3, 1)                     // hash, subject, blank line above
+	return listW, diffW, h
+}
+
+// splitDiff returns the part of the active commit's patch for the file
+// selected in the split layout.
+func (m Model) splitDiff() []types.DiffLine {
+	files := m.activeCommit.Files
+	if m.splitFile >= len(files) {
+		return nil
+	}
+	return fileDiff(m.activeCommit.Diff, files[m.splitFile].Path, m.splitFile)
+}
+
+// fileDiff picks path's section out of a multi-file patch. It falls back to
+// the idx-th section when no "diff --git" header names the path.
+func fileDiff(diff []types.DiffLine, path string, idx int) []types.DiffLine {
+	var sections [][]types.DiffLine
+	for _, dl := range diff {
+		if dl.Type == "diff" || len(sections) == 0 {
+			sections = append(sections, nil)
+		}
+		sections[len(sections)-1] = append(sections[len(sections)-1], dl)
+	}
+	for _, sec := range sections {
+		if sec[0].Type == "diff" && strings.HasSuffix(sec[0].Content, " b/"+path) {
+			return sec
+		}
+	}
+	if idx < len(sections) {
+		return sections[idx]
+	}
+	return nil
+}
+
+// renderCommitSplit renders the commit overlay as two columns: the changed
+// files, and the diff of the selected one.
+func (m Model) renderCommitSplit() string {
+	innerW, _ := m.commitDetailSize()
+	listW, diffW, h := m.commitSplitSize()
+	cd := m.activeCommit
+
+	hashStr := lipgloss.NewStyle().Foreground(clrFlamingo).Render(cd.ShortHash)
+	head := []string{
+		hashStr + "  " + lipgloss.NewStyle().Foreground(clrCommitContext).Render(cd.RelTime),
+		lipgloss.NewStyle().Bold(true).Foreground(clrCommitTitle).Render(truncate(cd.Subject, innerW)),
+		"",
+	}
+
+	var left, right []string
+	switch {
+	case !cd.Loaded:
+		left = append(left, dimStyle.Render("Loading…"))
+	case len(cd.Files) == 0:
+		left = append(left, dimStyle.Render("No files changed."))
+	default:
+		// Keep the selected file in view.
+		start := 0
+		if m.splitFile >= h {
+			start = m.splitFile - h + 1
+		}
+		for i := start; i < len(cd.Files) && i < start+h; i++ {
+			f := cd.Files[i]
+			marker, path := "  ", dimStyle.Render(truncate(f.Path, listW-4))
+			if i == m.splitFile {
+				marker = selectedAccentStyle.Render("▌") + " "
+				path = lipgloss.NewStyle().Foreground(clrCommitTitle).Render(truncate(f.Path, listW-4))
+			}
+			status := lipgloss.NewStyle().Foreground(fileColor(f.Status)).Render(f.Status)
+			left = append(left, marker+status+" "+path)
+		}
+		diff := m.splitDiff()
+		end := min(m.splitDiffScroll+h, len(diff))
+		for _, dl := range diff[min(m.splitDiffScroll, end):end] {
+			right = append(right, renderDiffLine(dl, diffW))
+		}
+	}
+
+	sep := sectionDividerStyle.Render(" │ ")
+	rows := head
+	for i := 0; i < h; i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		rows = append(rows, padRight(l, listW)+sep+r)
+	}
+
+	scrollInfo := ""
+	if n := len(m.splitDiff()); n > h {
+		scrollInfo = "  " + dimStyle.Render(fmt.Sprintf("%d/%d", m.splitDiffScroll+1, n))
+	}
+	hints := m.renderHints("↑↓/tab  file", "pgup/pgdn  scroll diff", "g/G  top/bottom", "s  stacked", "o/O  open file/working copy", "y/Y  copy link/files", "esc  close") + scrollInfo
+	body := strings.Join(rows, "\n") + "\n\n" + hints
+	return modalStyle.Width(innerW).Render(body)
+}
+
+// renderActivityOverlay renders recent commits from every worktree, newest
+// first, sized like the commit overlay.
+func (m Model) renderStashOverlay() string {
+	innerW, scrollH := m.commitDetailSize()
+	listH := scrollH - 4 // title, target line and blank lines
+
+	var rows []string
+	if m.stashes == nil {
+		rows = append(rows, dimStyle.Render("loading…"))
+	} else if len(m.stashes) == 0 {
+		rows = append(rows, dimStyle.Render("No stash entries."))
+	}
+	start := 0
+	if m.stashCursor >= listH {
+		start = m.stashCursor - listH + 1
+	}
+	for i := start; i < len(m.stashes) && i < start+listH; i++ {
+		e := m.stashes[i]
+		// Width(innerW) below includes the 2+2 padding.
+		msgW := innerW - 4 - 2 - 10 - 2 - 7 - 2 - 16 // time column
+		if msgW < 10 {
+			msgW = 10
+		}
+		marker, msgStyle := commitDotStyle.Render("●"), commitMsgStyle
+		if i == m.stashCursor {
+			marker, msgStyle = selectedAccentStyle.Render("▌"), selectedItemStyle
+		}
+		rows = append(rows, fmt.Sprintf("%s %s  %s  %s  %s",
+			marker,
+			headerBranchStyle.Render(padRight(e.Ref, 10)),
+			lipgloss.NewStyle().Foreground(clrFlamingo).Render(e.Hash),
+			msgStyle.Render(padRight(truncate(e.Subject, msgW), msgW)),
+			commitTimeStyle.Render(e.RelTime),
+		))
+	}
+	for len(rows) < listH {
+		rows = append(rows, "")
+	}
+
+	hints := m.renderHints("↑↓  navigate", "enter  diff", "a  apply", "p  pop", "d  drop", "esc  close")
+	if m.stashDropArmed && m.stashCursor < len(m.stashes) {
+		hints = dangerStyle.Render("drop "+m.stashes[m.stashCursor].Ref+"? d again to confirm") +
+			footerStyle.Render("    ") + m.renderHints("any other key  cancel")
+	}
+	body := lipgloss.JoinVertical(lipgloss.Left,
+		modalTitleStyle.Render("Stashes"),
+		dimStyle.Render("apply and pop go to "+m.stashName),
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		hints,
+	)
+	return modalStyle.Width(innerW).Render(body)
+}
+
+func (m Model) renderActivityOverlay() string {
+	innerW, scrollH := m.commitDetailSize()
+	listH := scrollH - 2 // title + blank line
+
+	var rows []string
+	if len(m.activity) == 0 {
+		rows = append(rows, dimStyle.Render("No commits yet."))
+	}
+	// Keep the cursor in view.
+	start := 0
+	if m.activityCursor >= listH {
+		start = m.activityCursor - listH + 1
+	}
+	for i := start; i < len(m.activity) && i < start+listH; i++ {
+		e := m.activity[i]
+		where := e.worktrees[0]
+		if n := len(e.worktrees); n > 1 {
+			where += fmt.Sprintf(" +%d", n-1)
+		}
+		where = truncate(where, 24)
+		// Width(innerW) below includes the 2+2 padding.
+		msgW := innerW - 4 - 2 - 7 - 2 - 24 - 2 - 16 // time column
+		if msgW < 10 {
+			msgW = 10
+		}
+		marker, msgStyle := commitDotStyle.Render("●"), commitMsgStyle
+		if i == m.activityCursor {
+			marker, msgStyle = selectedAccentStyle.Render("▌"), selectedItemStyle
+		}
+		rows = append(rows, fmt.Sprintf("%s %s  %s  %s  %s",
+			marker,
+			lipgloss.NewStyle().Foreground(clrFlamingo).Render(e.commit.Hash),
+			msgStyle.Render(padRight(truncate(e.commit.Message, msgW), msgW)),
+			headerBranchStyle.Render(padRight(where, 24)),
+			commitTimeStyle.Render(e.commit.RelTime),
+		))
+	}
+	for len(rows) < listH {
+		rows = append(rows, "")
+	}
+
+	body := lipgloss.JoinVertical(lipgloss.Left,
+		modalTitleStyle.Render("Recent activity"),
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		m.renderHints("↑↓  navigate", "enter  view commit", "esc  close"),
+	)
+	return modalStyle.Width(innerW).Render(body)
+}
+
+// branchAgeBuckets are the histogram bins of the branch ages view.
+var branchAgeBuckets = []struct {
+	label string
+	max   time.Duration // exclusive; 0 = unbounded
+}{
+	{"< 1 week", 7 * 24 * time.Hour},
+	{"1–4 weeks", 28 * 24 * time.Hour},
+	{"1–3 months", 91 * 24 * time.Hour},
+	{"older", 0},
+}
+
+// renderBranchAgesOverlay renders how long each branch has been in flight,
+// oldest first, with a bar per branch and a histogram of the spread.
+func (m Model) renderBranchAgesOverlay() string {
+	innerW, scrollH := m.commitDetailSize()
+	title := modalTitleStyle.Render("Branch ages") + dimStyle.Render("  vs "+m.compareBranch())
+
+	var rows []string
+	switch {
+	case m.branchAges == nil:
+		rows = append(rows, dimStyle.Render("Loading…"))
+	case len(m.branchAges) == 0:
+		rows = append(rows, dimStyle.Render("No branches besides "+m.compareBranch()+"."))
+	default:
+		now := time.Now()
+		counts := make([]int, len(branchAgeBuckets))
+		for _, a := range m.branchAges {
+			age := now.Sub(a.start)
+			for i, b := range branchAgeBuckets {
+				if b.max == 0 || age < b.max {
+					counts[i]++
+					break
+				}
+			}
+		}
+		for i, b := range branchAgeBuckets {
+			rows = append(rows, fmt.Sprintf("%s %s %d",
+				dimStyle.Render(padRight(b.label, 11)),
+				accentStyle.Render(strings.Repeat("■", counts[i])),
+				counts[i]))
+		}
+		rows = append(rows, "")
+
+		// Width(innerW) below includes the 2+2 padding.
+		nameW := 24
+		barW := innerW - 4 - nameW - 2 - 6 - 4
+		if barW < 10 {
+			barW = 10
+		}
+		oldest := now.Sub(m.branchAges[0].start)
+		listH := scrollH - 4 - len(rows) // title, blank, blank, footnote
+		start := m.branchAgesScroll
+		if last := len(m.branchAges) - listH; start > last {
+			start = max(last, 0)
+		}
+		for i := start; i < len(m.branchAges) && i < start+listH; i++ {
+			a := m.branchAges[i]
+			age := now.Sub(a.start)
+			n := barW
+			if oldest > 0 {
+				n = max(int(float64(barW)*float64(age)/float64(oldest)), 1)
+			}
+			ageStyle := detailValueStyle
+			switch {
+			case age >= 91*24*time.Hour:
+				ageStyle = dangerStyle
+			case age >= 28*24*time.Hour:
+				ageStyle = warningStyle
+			}
+			mark := " "
+			if !a.unique {
+				mark = dimStyle.Render("◦")
+			}
+			rows = append(rows, fmt.Sprintf("%s  %s %s %s",
+				headerBranchStyle.Render(padRight(truncate(a.name, nameW), nameW)),
+				ageStyle.Render(padRight(a.age, 5)),
+				mark,
+				ageStyle.Render(strings.Repeat("█", n))))
+		}
+	}
+
+	body := lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		dimStyle.Render("age since the first commit not on "+m.compareBranch()+"; ◦ since the fork point (no own commits)"),
+		m.renderHints("↑↓  scroll", "esc  close"),
+	)
+	return modalStyle.Width(innerW).Render(body)
+}
+
+// commitDetailLines builds the scrollable content of the Level 3 overlay.
+func (m Model) commitDetailLines(innerW int) []string {
 	cd := m.activeCommit
 	var lines []string
 
 	// ── Header: hash + reltime ─────────────────────────────────────────────
-	hashStr := lipgloss.NewStyle().Foreground(clrFlamingo).Render(cd.ShortHash)
+	label := cd.ShortHash
+	if m.state == types.StateWorkingDiff {
+		label = "working tree vs HEAD"
+	}
+	hashStr := lipgloss.NewStyle().Foreground(clrFlamingo).Render(label)
 	timeStr := lipgloss.NewStyle().Foreground(clrCommitContext).Render(cd.RelTime)
 	gap := innerW - lipgloss.Width(hashStr) - lipgloss.Width(timeStr)
 	if gap < 1 {
 		gap = 1
 	}
 	lines = append(lines, hashStr+strings.Repeat(" ", gap)+timeStr)
+	if by := commitPeopleLine(cd); by != "" {
+		lines = append(lines, by)
+	}
 	lines = append(lines, "")
 
 	// ── Subject ────────────────────────────────────────────────────────────
@@ -594,9 +2276,20 @@ func (m Model) renderCommitDetailOverlay() string {
 	if !cd.Loaded {
 		lines = append(lines, "")
 		lines = append(lines, dimStyle.Render("Loading…"))
+	} else if m.state == types.StateWorkingDiff && len(cd.Files) == 0 && len(cd.Untracked) == 0 {
+		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Foreground(clrGreen).Render("✓ no changes"))
 	} else {
+		// A root commit adds the whole tree: summarise it unless asked.
+		collapsed := cd.Root && !m.rootDiffShown
+		if collapsed {
+			lines = append(lines, "")
+			lines = append(lines, accentStyle.Render(fmt.Sprintf("initial commit (%d files)", len(cd.Files)))+
+				"  "+dimStyle.Render("d to show the files and diff"))
+		}
+
 		// ── Files changed ──────────────────────────────────────────────────
-		if len(cd.Files) > 0 {
+		if len(cd.Files) > 0 && !collapsed {
 			lines = append(lines, "")
 			hdr := fmt.Sprintf("Files changed (%d) ", len(cd.Files))
 			divW := innerW - lipgloss.Width(hdr)
@@ -605,19 +2298,17 @@ func (m Model) renderCommitDetailOverlay() string {
 			}
 			lines = append(lines, sectionDividerStyle.Render(hdr+strings.Repeat("─", divW)))
 			lines = append(lines, "")
-			for _, f := range cd.Files {
-				var sc lipgloss.Color
-				switch f.Status {
-				case "A":
-					sc = clrFileAdded
-				case "D":
-					sc = clrFileDeleted
-				case "R":
-					sc = clrFileRenamed
-				default:
-					sc = clrFileModified
+			for i, f := range cd.Files {
+				sc := fileColor(f.Status)
+				if m.state == types.StateWorkingDiff {
+					lines = append(lines, m.workingFileRow(i, f, sc))
+					continue
+				}
+				cursor := "  "
+				if i == m.splitFile {
+					cursor = selectedAccentStyle.Render("▌") + " "
 				}
-				lines = append(lines, fmt.Sprintf("%s  %s  %s",
+				lines = append(lines, fmt.Sprintf("%s%s  %s  %s", cursor,
 					commitDotStyle.Render("●"),
 					lipgloss.NewStyle().Foreground(sc).Render(f.Status),
 					lipgloss.NewStyle().Foreground(clrCommitTitle).Render(f.Path),
@@ -625,8 +2316,24 @@ func (m Model) renderCommitDetailOverlay() string {
 			}
 		}
 
+		// ── Untracked (working diff only) ──────────────────────────────────
+		if len(cd.Untracked) > 0 {
+			lines = append(lines, "")
+			hdr := fmt.Sprintf("Untracked (%d) ", len(cd.Untracked))
+			divW := max(innerW-lipgloss.Width(hdr), 0)
+			lines = append(lines, sectionDividerStyle.Render(hdr+strings.Repeat("─", divW)))
+			lines = append(lines, "")
+			for _, p := range cd.Untracked {
+				lines = append(lines, fmt.Sprintf("  %s  %s  %s",
+					dimStyle.Render("○"),
+					dimStyle.Render("?"),
+					lipgloss.NewStyle().Foreground(clrCommitTitle).Render(p),
+				))
+			}
+		}
+
 		// ── Diff ───────────────────────────────────────────────────────────
-		if len(cd.Diff) > 0 {
+		if len(cd.Diff) > 0 && !collapsed {
 			lines = append(lines, "")
 			diffHdr := "Diff "
 			divW := innerW - lipgloss.Width(diffHdr)
@@ -636,82 +2343,171 @@ func (m Model) renderCommitDetailOverlay() string {
 			lines = append(lines, sectionDividerStyle.Render(diffHdr+strings.Repeat("─", divW)))
 			lines = append(lines, "")
 			for _, dl := range cd.Diff {
-				var rendered string
-				switch dl.Type {
-				case "+":
-					rendered = lipgloss.NewStyle().Foreground(clrDiffAdded).Render(truncate(dl.Content, innerW))
-				case "-":
-					rendered = lipgloss.NewStyle().Foreground(clrDiffRemoved).Render(truncate(dl.Content, innerW))
-				case "@@":
-					rendered = lipgloss.NewStyle().Foreground(clrAccent).Render(truncate(dl.Content, innerW))
-				case "diff":
-					rendered = lipgloss.NewStyle().Bold(true).Render(truncate(dl.Content, innerW))
-				case "meta":
-					rendered = dimStyle.Render(truncate(dl.Content, innerW))
-				default:
-					rendered = lipgloss.NewStyle().Foreground(clrCommitContext).Render(truncate(dl.Content, innerW))
-				}
-				lines = append(lines, rendered)
+				lines = append(lines, renderDiffLine(dl, innerW))
 			}
 		}
 	}
+	return lines
+}
 
-	// ── Apply scroll ───────────────────────────────────────────────────────
-	total := len(lines)
-	maxScroll := total - scrollH
-	if maxScroll < 0 {
-		maxScroll = 0
-	}
-	scroll := m.commitDetailScroll
-	if scroll > maxScroll {
-		scroll = maxScroll
+// renderDiffLine colours one patch line by type, truncated to w.
+func renderDiffLine(dl types.DiffLine, w int) string {
+	switch dl.Type {
+	case "+":
+		return lipgloss.NewStyle().Foreground(clrDiffAdded).Render(truncate(dl.Content, w))
+	case "-":
+		return lipgloss.NewStyle().Foreground(clrDiffRemoved).Render(truncate(dl.Content, w))
+	case "@@":
+		return lipgloss.NewStyle().Foreground(clrAccent).Render(truncate(dl.Content, w))
+	case "diff":
+		return lipgloss.NewStyle().Bold(true).Render(truncate(dl.Content, w))
+	case "meta":
+		return dimStyle.Render(truncate(dl.Content, w))
+	default:
+		return lipgloss.NewStyle().Foreground(clrCommitContext).Render(truncate(dl.Content, w))
 	}
-	visible := lines
-	if scroll > 0 && scroll < len(lines) {
-		visible = lines[scroll:]
+}
+
+// fileColor is the colour for a file's name-status letter.
+func fileColor(status string) lipgloss.Color {
+	switch status {
+	case "A":
+		return clrFileAdded
+	case "D":
+		return clrFileDeleted
+	case "R":
+		return clrFileRenamed
+	default:
+		return clrFileModified
 	}
-	if len(visible) > scrollH {
-		visible = visible[:scrollH]
+}
+
+// workingFileRow renders a working-diff file with its cursor and a marker
+// for where its changes sit: ● staged, ○ unstaged, ◐ both.
+func (m Model) workingFileRow(i int, f types.CommitFile, statusClr lipgloss.Color) string {
+	cursor := "  "
+	if i == m.workingDiffFile {
+		cursor = selectedAccentStyle.Render("▌") + " "
 	}
-	for len(visible) < scrollH {
-		visible = append(visible, "")
+	mark := dimStyle.Render("○")
+	switch {
+	case f.Staged && f.Unstaged:
+		mark = warningStyle.Render("◐")
+	case f.Staged:
+		mark = lipgloss.NewStyle().Foreground(clrGreen).Render("●")
 	}
+	return fmt.Sprintf("%s%s  %s  %s", cursor, mark,
+		lipgloss.NewStyle().Foreground(statusClr).Render(f.Status),
+		lipgloss.NewStyle().Foreground(clrCommitTitle).Render(f.Path),
+	)
+}
 
-	// ── Scroll indicator ───────────────────────────────────────────────────
-	// Append a simple N/M indicator when content overflows.
-	scrollInfo := ""
-	if total > scrollH {
-		scrollInfo = "  " + dimStyle.Render(fmt.Sprintf("%d/%d", scroll+1, total))
+// commitPeopleLine renders the author (and committer, when a rebase or
+// cherry-pick made them differ) plus the signature status.
+func commitPeopleLine(cd types.CommitDetail) string {
+	if cd.Author == "" {
+		return ""
 	}
-
-	hints := m.renderHints("↑↓  scroll", "esc  close") + scrollInfo
-	body := strings.Join(visible, "\n") + "\n\n" + hints
-
-	return lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(clrAccent).
-		Padding(1, 2).
-		Width(innerW).
-		Render(body)
+	ctx := lipgloss.NewStyle().Foreground(clrCommitContext)
+	line := ctx.Render("by " + cd.Author)
+	if cd.Committer != "" && cd.Committer != cd.Author {
+		line = ctx.Render("authored by "+cd.Author+", ") + warningStyle.Render("committed by "+cd.Committer)
+	}
+	switch cd.Signature {
+	case "G":
+		line += "  " + lipgloss.NewStyle().Foreground(clrGreen).Render("✓ signed")
+	case "B", "R":
+		line += "  " + dangerStyle.Render("✗ bad signature")
+	case "U", "X", "Y", "E":
+		line += "  " + warningStyle.Render("? signed (unverified)")
+	}
+	return line
 }
 
 // ── Footer ────────────────────────────────────────────────────────────────────
 
+// quitPrompt replaces the footer between the two presses of ctrl+c.
+const quitPrompt = "press ctrl+c again to quit"
+
 func (m Model) renderFooter() string {
+	if m.quitArmed {
+		return warningStyle.Render(quitPrompt)
+	}
 	if m.errMsg != "" {
 		return dangerStyle.Render("error: "+m.errMsg) + footerStyle.Render("    (any key to dismiss)")
 	}
+	hints := m.renderStateHints()
+	if m.statusMsg != "" {
+		hints = statusStyle.Render(m.statusMsg) + footerStyle.Render("    ") + hints
+	}
+	if m.cfg.ReadOnly {
+		return warningStyle.Render("read-only mode") + footerStyle.Render("    ") + hints
+	}
+	return hints
+}
+
+// renderStateHints returns the key hints for the current state.
+func (m Model) renderStateHints() string {
 	switch m.state {
 	case types.StateList:
-		if m.cursor == 0 {
-			return m.renderHints("n  new", "↑↓  navigate", "q  quit")
+		var hints []string
+		if m.cursor == 0 || (m.cursor-1 < len(m.worktrees) && m.worktrees[m.cursor-1].IsMain) {
+			hints = []string{"n  new", "w  branches", "C  check out PR", "O/T  repo root editor/shell", "r  repos", "↑↓  navigate"}
+		} else if m.worktrees[m.cursor-1].Missing {
+			hints = []string{"x  prune", "n  new", "w  branches", "↑↓  navigate"}
+		} else {
+			hints = []string{"n  new", "D  duplicate", "w  branches", "d  delete", "p  push", "M  merge into default", "P  create PR", "C  check out PR", "e  edit", "m  move", "N  notes", "E  scratchpad", "L  labels", "*  pin", "F  fetch", "ctrl+f  fetch all", "v  changes", "+/-  stage/unstage all", "i  .git link", "A  activity", "S  branch ages", "f  files", "c  cd", "o/t  editor/shell", "enter  focus", "↑↓  navigate", "]d/[d  next/prev dirty"}
+		}
+		if m.enclosingRepo != "" {
+			hints = append(hints, "R  parent repo")
+		}
+		if len(m.finished()) > 0 {
+			hints = append(hints, "X  remove finished")
 		}
-		if m.cursor-1 < len(m.worktrees) && m.worktrees[m.cursor-1].IsMain {
-			return m.renderHints("n  new", "↑↓  navigate", "q  quit")
+		if m.stashCount > 0 {
+			hints = append(hints, "$  stashes")
 		}
-		return m.renderHints("n  new", "d  delete", "e  edit", "c  cd", "enter  focus", "↑↓  navigate", "q  quit")
+		if m.labelFilter != "" {
+			hints = append(hints, "l  label: "+m.labelFilter)
+		} else {
+			hints = append(hints, "l  label filter")
+		}
+		if m.mineOnly {
+			hints = append(hints, "a  all authors")
+		} else {
+			hints = append(hints, "a  mine only")
+		}
+		if m.compareBranchOverride != "" {
+			hints = append(hints, "b  compare to "+m.defaultBranch)
+		} else {
+			hints = append(hints, "B  compare to this")
+		}
+		if m.grouped {
+			hints = append(hints, "g  ungroup", "z/Z  fold/unfold all")
+		} else {
+			hints = append(hints, "g  group by type")
+		}
+		switch m.repoState.SortMode {
+		case sortCommitted:
+			hints = append(hints, "s  sort: committed")
+		case sortAccessed:
+			hints = append(hints, "s  sort: accessed")
+		default:
+			hints = append(hints, "s  sort")
+		}
+		if len(m.metaUndo) > 0 {
+			hints = append(hints, "u  undo edit")
+		}
+		return m.renderHints(append(hints, "ctrl+r  reload config", "q  quit")...)
 	case types.StateRightPaneFocused:
-		return m.renderHints("↑↓  navigate commits", "enter  view", "esc  back", "q  quit")
+		mode := "u  unmerged only"
+		if m.unmergedOnly {
+			mode = "u  all commits"
+		}
+		if m.rightPaneInnerW() >= wideCommitListW {
+			return m.renderHints("↑↓  navigate commits", "←→  columns", "enter  view", mode, "F  fetch", "esc  back", "q  quit")
+		}
+		return m.renderHints("↑↓  navigate commits", "enter  view", mode, "F  fetch", "esc  back", "q  quit")
 	default:
 		return m.renderHints("q  quit")
 	}
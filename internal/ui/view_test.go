@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agnishcc/worktree-tui/internal/types"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// snapshotModel is a list of three worktrees with every field the list and
+// detail pane show filled in from fixed values, so renders are repeatable.
+func snapshotModel() Model {
+	m := listModel("feat/login", "fix/crash")
+	m.repoName, m.curBranch, m.defaultBranch = "proj", "main", "main"
+	m.remoteURL, m.fetchedAgo = "github.com/acme/proj", "5m ago"
+	commits := []types.Commit{
+		{Hash: "a1b2c3d", Message: "Add login form", RelTime: "2 hours ago", Time: 2},
+		{Hash: "e4f5a6b", Message: "Wire up session store", RelTime: "1 day ago", Time: 1},
+	}
+	for i := range m.allWorktrees {
+		wt := &m.allWorktrees[i]
+		wt.Enriched = true
+		wt.HeadSHA = "a1b2c3d"
+		wt.UpdatedAt = "2 hours ago"
+		wt.LastAuthor, wt.LastAuthorEmail = "Ada", "ada@example.com"
+		wt.Commits = commits
+	}
+	login := &m.allWorktrees[1]
+	login.Name = "Login page"
+	login.Description = "New login flow behind a flag"
+	login.CreatedFrom = "9f8e7d6"
+	login.Ahead, login.Behind = 2, 1
+	login.MergeBase, login.ForkedAge = "9f8e7d6", "3 days ago"
+	login.StatusChanged, login.StatusStaged, login.StatusUnstaged, login.StatusUntracked = 2, 1, 1, 1
+	login.Labels = []string{"review"}
+	m.worktrees = m.visibleWorktrees()
+	return m
+}
+
+// checkGolden compares got with testdata/<name>.golden, rewriting the file
+// instead when the test runs with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./internal/ui -run %s -update to create it)", err, t.Name())
+	}
+	if got != string(want) {
+		t.Errorf("%s render changed (rerun with -update if intended):\n%s", name, got)
+	}
+}
+
+func TestPlainViewGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Model)
+	}{
+		{"list", func(m *Model) {}},
+		{"detail", func(m *Model) { m.cursor = 2 }},
+		{"new-modal", func(m *Model) {
+			m.openNewModal()
+			m.newDisplayName = "Dark mode"
+			m.newActiveField = 1
+			m.recalcBranch()
+		}},
+		{"commit-overlay", func(m *Model) {
+			m.cursor = 2
+			m.activeCommitPath = m.worktrees[1].Path
+			m.activeCommit = types.CommitDetail{
+				ShortHash: "a1b2c3d",
+				Subject:   "Add login form",
+				Body:      "Renders the form and posts to /session.",
+				RelTime:   "2 hours ago",
+				Author:    "Ada <ada@example.com>",
+				Committer: "Ada <ada@example.com>",
+				Signature: "N",
+				Files:     []types.CommitFile{{Status: "A", Path: "web/login.html"}, {Status: "M", Path: "web/app.js"}},
+				Diff: []types.DiffLine{
+					{Type: "diff", Content: "diff --git a/web/app.js b/web/app.js"},
+					{Type: "meta", Content: "--- a/web/app.js"},
+					{Type: "meta", Content: "+++ b/web/app.js"},
+					{Type: "@@", Content: "@@ -1,2 +1,3 @@"},
+					{Type: " ", Content: " import { session } from './session.js'"},
+					{Type: "-", Content: "-render(home)"},
+					{Type: "+", Content: "+render(login)"},
+					{Type: "+", Content: "+session.watch()"},
+				},
+				Loaded: true,
+			}
+			m.overlayReturn = types.StateRightPaneFocused
+			m.state = types.StateCommitDetail
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := snapshotModel()
+			tt.setup(&m)
+			checkGolden(t, tt.name, m.PlainView(100, 30))
+		})
+	}
+}
+
+func TestPlainViewHasNoEscapes(t *testing.T) {
+	m := snapshotModel()
+	m.cursor = 2
+	if out := m.PlainView(100, 30); strings.Contains(out, "\x1b[") {
+		t.Error("PlainView output contains ANSI escape sequences")
+	}
+}
@@ -1,21 +1,204 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/agnishcc/worktree-tui/internal/config"
+	"github.com/agnishcc/worktree-tui/internal/git"
 	"github.com/agnishcc/worktree-tui/internal/ui"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "new" {
+		os.Exit(runNew(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor())
+	}
+
+	noShellPrompt := flag.Bool("no-shell-prompt", false, "never show the shell integration prompt")
+	readOnly := flag.Bool("read-only", false, "disable create/delete/rename and other mutating actions")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if *noShellPrompt {
+		cfg.NoShellPrompt = true
+	}
+	if *readOnly {
+		cfg.ReadOnly = true
+	}
+
+	// The alt screen and box drawing need a real terminal; in a pipe or
+	// under TERM=dumb they come out as garbage.
+	if !isInteractive() {
+		fmt.Fprintln(os.Stderr, "worktree-tui requires an interactive terminal; use `worktree-tui new` for scripted creation")
+		os.Exit(1)
+	}
+
 	p := tea.NewProgram(
-		ui.InitialModel(),
+		ui.InitialModel(cfg),
 		tea.WithAltScreen(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	ui.RemoveSnapshots()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	if m, ok := final.(ui.Model); ok && m.ExitMessage() != "" {
+		fmt.Println(m.ExitMessage())
+	}
+}
+
+// isInteractive reports whether stdout is a terminal capable of the full UI.
+func isInteractive() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// runNew implements `worktree-tui new <branch> [--from <ref>] [--desc <text>]
+// [--template <name>] [--force]`: it creates the worktree the way the TUI's
+// form does and prints its path, so scripts can cd into it.
+func runNew(args []string) int {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	from := fs.String("from", "", "ref to start the branch from (default HEAD)")
+	desc := fs.String("desc", "", "description stored with the worktree")
+	tmplName := fs.String("template", "", "configured template to apply (base, path, commands)")
+	force := fs.Bool("force", false, "create even past maxWorktrees or when a tag has the branch's name")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: worktree-tui new <branch> [--from <ref>] [--desc <text>] [--template <name>] [--force]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	// Allow flags after the branch as well as before it.
+	var branch string
+	if fs.NArg() > 0 {
+		branch = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+	if branch == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintf(os.Stderr, "worktree-tui new: %v\n", err)
+		return 1
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if cfg.ReadOnly {
+		return fail(fmt.Errorf("read-only mode is on"))
+	}
+	if !git.IsGitRepo() {
+		return fail(fmt.Errorf("not inside a git repository"))
+	}
+	if err := git.CheckBranchName(branch); err != nil {
+		return fail(err)
+	}
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return fail(err)
+	}
+	path := ui.WorktreePath(root, branch)
+	var tmpl *config.Template
+	if *tmplName != "" {
+		for i := range cfg.Templates {
+			if cfg.Templates[i].Name == *tmplName {
+				tmpl = &cfg.Templates[i]
+			}
+		}
+		if tmpl == nil {
+			return fail(fmt.Errorf("no template named %q", *tmplName))
+		}
+		if tmpl.Path != "" {
+			path = git.ExpandPath(tmpl.Expand(tmpl.Path, root, branch, branch), root)
+		}
+		if *desc == "" {
+			*desc = tmpl.Expand(tmpl.Description, root, branch, branch)
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fail(fmt.Errorf("%s already exists", path))
+	}
+	// The form asks to confirm these; here --force is the confirmation.
+	if !*force {
+		wts, err := git.ListWorktrees(true, "")
+		if err != nil {
+			return fail(err)
+		}
+		if w := ui.CreationWarnings(cfg, branch, len(wts)); len(w) > 0 {
+			return fail(fmt.Errorf("%s (use --force to create anyway)", strings.Join(w, "; ")))
+		}
+	}
+
+	created, hookOut, err := ui.CreateWorktree("", branch, path, *desc, *from, tmpl, cfg.SignCommits)
+	if hookOut != "" {
+		fmt.Fprintln(os.Stderr, hookOut)
+	}
+	if created {
+		fmt.Println(path)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// runDoctor implements `worktree-tui doctor`: it reports worktrees in a state
+// where actions could land on the wrong one or fail, and exits 1 if it found
+// any.
+func runDoctor() int {
+	if !git.IsGitRepo() {
+		fmt.Fprintln(os.Stderr, "worktree-tui doctor: not inside a git repository")
+		return 1
+	}
+	wts, err := git.ListWorktrees(true, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "worktree-tui doctor: %v\n", err)
+		return 1
+	}
+	var problems []string
+	for _, wt := range wts {
+		if len(wt.SharedWith) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: branch %s is also checked out in %s",
+				wt.Path, wt.Branch, strings.Join(wt.SharedWith, ", ")))
+		}
+		if len(wt.NameClashWith) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: name %q is also used by %s",
+				wt.Path, wt.Name, strings.Join(wt.NameClashWith, ", ")))
+		}
+		if wt.Prunable {
+			problems = append(problems, fmt.Sprintf("%s: directory is missing; git worktree prune removes the entry", wt.Path))
+		}
+		if wt.InProgressOp != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s in progress", wt.Path, wt.InProgressOp))
+		}
+	}
+	if len(problems) == 0 {
+		fmt.Println("no problems found")
+		return 0
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	return 1
 }